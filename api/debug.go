@@ -0,0 +1,102 @@
+package api
+
+import (
+	"constellation-api/pkg/shared/httperr"
+	"net/http"
+)
+
+// requireNATS writes a 500 and returns false if the embedded NATS/JetStream
+// connection isn't available, so the /debug/nats/* handlers below can bail
+// out consistently.
+func (h *Handlers) requireNATS(w http.ResponseWriter) bool {
+	if h.nats == nil || h.nats.JetStream() == nil {
+		writeError(w, httperr.ErrInternal(nil).WithDetails("JetStream is not available"))
+		return false
+	}
+	return true
+}
+
+// ListNATSStreams handles GET /debug/nats/streams, listing every JetStream
+// stream with its config, message/byte counts, first/last sequence, and
+// consumer count.
+func (h *Handlers) ListNATSStreams(w http.ResponseWriter, r *http.Request) {
+	if !h.requireNATS(w) {
+		return
+	}
+
+	infos, err := h.nats.ListStreamInfo()
+	if err != nil {
+		writeError(w, httperr.ErrInternal(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, infos)
+}
+
+// GetNATSStream handles GET /debug/nats/streams/{name}, returning detailed
+// state - including per-subject message counts and cluster/replica info -
+// for a single stream.
+func (h *Handlers) GetNATSStream(w http.ResponseWriter, r *http.Request) {
+	if !h.requireNATS(w) {
+		return
+	}
+
+	name := r.PathValue("name")
+	info, err := h.nats.StreamInfo(name)
+	if err != nil {
+		writeError(w, httperr.ErrNotFound("stream not found: "+name).WithCause(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, info)
+}
+
+// ListNATSConsumers handles GET /debug/nats/consumers/{stream}, listing each
+// consumer's pending, ack-pending, redelivered, and last-delivered sequence.
+func (h *Handlers) ListNATSConsumers(w http.ResponseWriter, r *http.Request) {
+	if !h.requireNATS(w) {
+		return
+	}
+
+	stream := r.PathValue("stream")
+	infos, err := h.nats.ListConsumerInfo(stream)
+	if err != nil {
+		writeError(w, httperr.ErrNotFound("stream not found: "+stream).WithCause(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, infos)
+}
+
+// PurgeNATSStream handles POST /debug/nats/streams/{name}/purge, deleting
+// every message currently stored on the stream.
+func (h *Handlers) PurgeNATSStream(w http.ResponseWriter, r *http.Request) {
+	if !h.requireNATS(w) {
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := h.nats.PurgeStream(name); err != nil {
+		writeError(w, httperr.ErrInternal(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, map[string]string{"message": "stream purged: " + name})
+}
+
+// ResetNATSConsumer handles POST /debug/nats/consumers/{stream}/{name}/reset,
+// deleting and recreating a consumer to clear its pending/redelivery state.
+func (h *Handlers) ResetNATSConsumer(w http.ResponseWriter, r *http.Request) {
+	if !h.requireNATS(w) {
+		return
+	}
+
+	stream := r.PathValue("stream")
+	name := r.PathValue("name")
+	if err := h.nats.ResetConsumer(stream, name); err != nil {
+		writeError(w, httperr.ErrInternal(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, map[string]string{"message": "consumer reset: " + name})
+}
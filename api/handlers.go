@@ -3,25 +3,49 @@ package api
 import (
 	"constellation-api/api/middleware"
 	"constellation-api/api/services"
+	"constellation-api/api/sse"
 	"constellation-api/pkg/ontology"
 	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/httperr"
+	"constellation-api/pkg/services/blobstore"
 	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/services/statecache"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/nats-io/nats.go"
 )
 
 type Handlers struct {
 	orgService    *services.OrganizationService
 	entityService *services.EntityService
+	nats          *embeddednats.EmbeddedNATS
+	stateCache    *statecache.Service
+	blobStore     *blobstore.Service
 }
 
-func NewHandlers(db *sql.DB, nats *embeddednats.EmbeddedNATS) *Handlers {
+// NewHandlers builds the HTTP handlers. entityService is shared with
+// workers.Manager (see workers.NewManager) so hooks registered on it run for
+// both the HTTP-facing write path and the NATS command-driven one.
+func NewHandlers(db *sql.DB, nats *embeddednats.EmbeddedNATS, entityService *services.EntityService) (*Handlers, error) {
+	stateCache, err := statecache.NewService(nats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind state cache: %w", err)
+	}
+
 	return &Handlers{
 		orgService:    services.NewOrganizationService(db),
-		entityService: services.NewEntityService(db, nats),
-	}
+		entityService: entityService,
+		nats:          nats,
+		stateCache:    stateCache,
+		blobStore:     blobstore.NewService(nats),
+	}, nil
 }
 
 // Organization handlers
@@ -32,9 +56,9 @@ func (h *Handlers) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	org, err := h.orgService.CreateOrganization(&req)
+	org, err := h.orgService.CreateOrganization(r.Context(), &req)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -42,9 +66,9 @@ func (h *Handlers) CreateOrganization(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) ListOrganizations(w http.ResponseWriter, r *http.Request) {
-	orgs, err := h.orgService.ListOrganizations()
+	orgs, err := h.orgService.ListOrganizations(r.Context())
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -57,14 +81,13 @@ func (h *Handlers) GetOrganization(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_ORG_ID", "org_id is required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
-	org, err := h.orgService.GetOrganization(orgID)
+	org, err := h.orgService.GetOrganization(r.Context(), orgID)
 	if err != nil {
-		if err.Error() == "organization not found" {
-			sendError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
-		} else {
-			sendError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
-		}
+		writeError(w, err)
 		return
 	}
 
@@ -78,6 +101,9 @@ func (h *Handlers) CreateEntity(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_ORG_ID", "org_id is required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
 	var req ontology.CreateEntityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -85,9 +111,9 @@ func (h *Handlers) CreateEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entity, err := h.entityService.CreateEntity(orgID, &req)
+	entity, err := h.entityService.CreateEntity(r.Context(), orgID, &req)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -100,10 +126,13 @@ func (h *Handlers) ListEntities(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_ORG_ID", "org_id is required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
-	entities, err := h.entityService.ListEntities(orgID)
+	entities, err := h.entityService.ListEntities(r.Context(), orgID)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -118,17 +147,17 @@ func (h *Handlers) GetEntity(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_PARAMS", "org_id and entity_id are required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
-	entity, err := h.entityService.GetEntity(orgID, entityID)
+	entity, err := h.entityService.GetEntity(r.Context(), orgID, entityID)
 	if err != nil {
-		if err.Error() == "entity not found" {
-			sendError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
-		} else {
-			sendError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
-		}
+		writeError(w, err)
 		return
 	}
 
+	setEntityETag(w, entity)
 	sendSuccess(w, http.StatusOK, entity)
 }
 
@@ -140,6 +169,9 @@ func (h *Handlers) UpdateEntity(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_PARAMS", "org_id and entity_id are required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
 	var updates map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
@@ -147,19 +179,66 @@ func (h *Handlers) UpdateEntity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entity, err := h.entityService.UpdateEntity(orgID, entityID, updates)
+	ifMatchVersion, err := expectedResourceVersion(r, updates)
 	if err != nil {
-		if err.Error() == "entity not found" {
-			sendError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
-		} else {
-			sendError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
-		}
+		sendError(w, http.StatusBadRequest, "INVALID_IF_MATCH", err.Error())
 		return
 	}
+	delete(updates, "resource_version")
 
+	entity, err := h.entityService.UpdateEntity(r.Context(), orgID, entityID, updates, ifMatchVersion)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	setEntityETag(w, entity)
 	sendSuccess(w, http.StatusOK, entity)
 }
 
+// expectedResourceVersion resolves the caller's expected ResourceVersion from
+// the If-Match header (preferred) or a resource_version field in the update
+// body, returning nil if neither was supplied.
+func expectedResourceVersion(r *http.Request, updates map[string]interface{}) (*int64, error) {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid If-Match header: %w", err)
+		}
+		return &version, nil
+	}
+
+	if raw, ok := updates["resource_version"]; ok {
+		version, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("resource_version must be a number")
+		}
+		v := int64(version)
+		return &v, nil
+	}
+
+	return nil, nil
+}
+
+// setEntityETag exposes the entity's ResourceVersion as a quoted ETag so
+// clients can round-trip it back via If-Match.
+func setEntityETag(w http.ResponseWriter, entity *ontology.Entity) {
+	w.Header().Set("ETag", fmt.Sprintf("%q", entity.ResourceVersion))
+}
+
+// requireOrgAccess rejects the request with 403 Forbidden unless the
+// Principal RequireScope attached to its context is a member of orgID,
+// preventing a caller scoped to one tenant from reaching another's data with
+// an otherwise-valid token.
+func requireOrgAccess(w http.ResponseWriter, r *http.Request, orgID string) bool {
+	principal, ok := middleware.PrincipalFromRequest(r)
+	if !ok || !principal.HasOrg(orgID) {
+		writeError(w, httperr.ErrForbidden("not a member of organization: "+orgID))
+		return false
+	}
+	return true
+}
+
 func (h *Handlers) DeleteEntity(w http.ResponseWriter, r *http.Request) {
 	orgID := r.URL.Query().Get("org_id")
 	entityID := r.URL.Query().Get("entity_id")
@@ -168,20 +247,139 @@ func (h *Handlers) DeleteEntity(w http.ResponseWriter, r *http.Request) {
 		sendError(w, http.StatusBadRequest, "MISSING_PARAMS", "org_id and entity_id are required")
 		return
 	}
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
 
-	err := h.entityService.DeleteEntity(orgID, entityID)
+	err := h.entityService.DeleteEntity(r.Context(), orgID, entityID)
 	if err != nil {
-		if err.Error() == "entity not found" {
-			sendError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
-		} else {
-			sendError(w, http.StatusInternalServerError, "DELETE_FAILED", err.Error())
-		}
+		writeError(w, err)
 		return
 	}
 
 	sendSuccess(w, http.StatusOK, map[string]string{"message": "Entity deleted successfully"})
 }
 
+// GetEntityState returns the latest known state for an entity from the state
+// cache, which reflects the most recent entity event seen by EntityWorker -
+// it may lag the authoritative row in services.EntityService by however long
+// the worker takes to process the event, but doesn't require a database round
+// trip. Supports conditional GETs: the entity's KV revision is exposed as an
+// ETag, and a matching If-None-Match returns 304 without a body.
+func (h *Handlers) GetEntityState(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("org")
+	entityID := r.PathValue("id")
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
+
+	entry, err := h.stateCache.GetEntity(orgID, entityID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	etag := fmt.Sprintf("%q", entry.Revision)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(entry.Data)
+}
+
+// GetEntityPresence returns the org's live presence roster: every entity
+// whose telemetry has refreshed the presence KV bucket within its TTL.
+func (h *Handlers) GetEntityPresence(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("org")
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
+
+	roster, err := h.stateCache.ListPresence(orgID)
+	if err != nil {
+		writeError(w, httperr.ErrInternal(err))
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, roster)
+}
+
+// UploadEntityBlob serves POST /api/v1/entities/{org}/{id}/blobs: a chunked
+// multipart/form-data upload of a single file part, streamed straight into
+// the org's blob bucket rather than buffered into memory first.
+func (h *Handlers) UploadEntityBlob(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("org")
+	entityID := r.PathValue("id")
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "INVALID_REQUEST", "expected multipart/form-data: "+err.Error())
+		return
+	}
+
+	part, err := mr.NextPart()
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "INVALID_REQUEST", "missing file part")
+		return
+	}
+	defer part.Close()
+
+	name := part.FileName()
+	if name == "" {
+		name = part.FormName()
+	}
+
+	info, err := h.blobStore.Put(r.Context(), orgID, entityID, name, r.URL.Query().Get("description"), nats.Header(part.Header), part)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendSuccess(w, http.StatusCreated, info)
+}
+
+// GetEntityBlob serves GET /api/v1/blobs/{org}/{name}: a streaming download
+// of a previously uploaded blob.
+func (h *Handlers) GetEntityBlob(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("org")
+	name := r.PathValue("name")
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
+
+	result, err := h.blobStore.Get(orgID, name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer result.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	io.Copy(w, result)
+}
+
+// DeleteEntityBlob serves DELETE /api/v1/blobs/{org}/{name}.
+func (h *Handlers) DeleteEntityBlob(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("org")
+	name := r.PathValue("name")
+	if !requireOrgAccess(w, r, orgID) {
+		return
+	}
+
+	if err := h.blobStore.Delete(orgID, name); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendSuccess(w, http.StatusOK, map[string]string{"message": "Blob deleted successfully"})
+}
+
 // Health check
 func (h *Handlers) HealthCheck(nats *embeddednats.EmbeddedNATS) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -233,7 +431,7 @@ func sendSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
 func sendError(w http.ResponseWriter, statusCode int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	
+
 	response := shared.Response{
 		Success: false,
 		Error: &shared.Error{
@@ -241,25 +439,76 @@ func sendError(w http.ResponseWriter, statusCode int, code, message string) {
 			Message: message,
 		},
 	}
-	
+
 	json.NewEncoder(w).Encode(response)
 }
 
+// writeError unwraps err to an *httperr.APIError (falling back to a generic
+// 500) and writes it, replacing the old per-handler switch on err.Error().
+func writeError(w http.ResponseWriter, err error) {
+	httperr.FromError(err).WriteTo(w)
+}
+
+// withTimeout is shorthand for the default RequestTimeout wrapping applied
+// to every non-streaming route below; it runs outermost so the deadline
+// covers scope/org checks as well as the handler body.
+func withTimeout(next http.HandlerFunc) http.HandlerFunc {
+	return middleware.RequestTimeout(middleware.DefaultRequestTimeout)(next)
+}
+
 // RegisterRoutes sets up all API routes
 func (h *Handlers) RegisterRoutes(mux *http.ServeMux, nats *embeddednats.EmbeddedNATS) {
 	// Health check (no auth required)
 	mux.HandleFunc("/health", h.HealthCheck(nats))
 
-	// Organization endpoints
+	// Real-time entity/event/telemetry streaming over WebSocket - no
+	// RequestTimeout here, since the connection is meant to live far longer
+	// than DefaultRequestTimeout; Stream's own context handles shutdown.
+	mux.HandleFunc("/api/v1/stream", middleware.RequireScope("entities:read")(h.Stream))
+
+	// Per-stream Server-Sent Events endpoints, for callers that want one
+	// JetStream stream at a time over plain HTTP rather than the multiplexed
+	// WebSocket above. Same no-RequestTimeout reasoning as /api/v1/stream.
+	sseHandlers := sse.NewHandlers(nats, h.stateCache)
+	mux.HandleFunc("/api/v1/stream/entities", middleware.RequireScope("entities:read")(sseHandlers.Entities))
+	mux.HandleFunc("/api/v1/stream/telemetry", middleware.RequireScope("telemetry:read")(sseHandlers.Telemetry))
+	mux.HandleFunc("/api/v1/stream/events", middleware.RequireScope("events:read")(sseHandlers.Events))
+	mux.HandleFunc("/api/v1/stream/commands", middleware.RequireScope("commands:read")(sseHandlers.Commands))
+	mux.HandleFunc("/api/v1/stream/entities/state", middleware.RequireScope("entities:read")(sseHandlers.EntitiesState))
+
+	// Entity state cache / presence roster - served from the JetStream KV
+	// buckets EntityWorker/TelemetryWorker keep warm, not the database.
+	mux.HandleFunc("GET /api/v1/entities/{org}/{id}/state", withTimeout(middleware.RequireScope("entities:read")(h.GetEntityState)))
+	mux.HandleFunc("GET /api/v1/entities/{org}/presence", withTimeout(middleware.RequireScope("entities:read")(h.GetEntityPresence)))
+
+	// Entity blobs (imagery, LIDAR frames, mission recordings) - no
+	// withTimeout, since DefaultRequestTimeout is sized for JSON CRUD, not a
+	// large chunked upload/download.
+	mux.HandleFunc("POST /api/v1/entities/{org}/{id}/blobs", middleware.RequireScope("entities:write")(h.UploadEntityBlob))
+	mux.HandleFunc("GET /api/v1/blobs/{org}/{name}", middleware.RequireScope("entities:read")(h.GetEntityBlob))
+	mux.HandleFunc("DELETE /api/v1/blobs/{org}/{name}", middleware.RequireScope("entities:write")(h.DeleteEntityBlob))
+
+	// Admin/debug JetStream introspection - gated by ADMIN_BEARER_TOKEN via
+	// middleware.AdminAuth, kept separate from the tenant-facing Authenticator
+	// (API_BEARER_TOKEN or JWT) so an ordinary API caller can't reach stream
+	// purge or consumer reset just by holding a valid tenant credential.
+	mux.HandleFunc("GET /debug/nats/streams", withTimeout(middleware.AdminAuth(h.ListNATSStreams)))
+	mux.HandleFunc("GET /debug/nats/streams/{name}", withTimeout(middleware.AdminAuth(h.GetNATSStream)))
+	mux.HandleFunc("GET /debug/nats/consumers/{stream}", withTimeout(middleware.AdminAuth(h.ListNATSConsumers)))
+	mux.HandleFunc("POST /debug/nats/streams/{name}/purge", withTimeout(middleware.AdminAuth(h.PurgeNATSStream)))
+	mux.HandleFunc("POST /debug/nats/consumers/{stream}/{name}/reset", withTimeout(middleware.AdminAuth(h.ResetNATSConsumer)))
+
+	// Organization endpoints - org management is administrative, so it
+	// requires admin:* rather than the tenant-scoped entities:* scopes.
 	mux.HandleFunc("/api/v1/organizations", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			middleware.BearerAuth(h.CreateOrganization)(w, r)
+			withTimeout(middleware.RequireScope("admin:*")(h.CreateOrganization))(w, r)
 		case http.MethodGet:
 			if r.URL.Query().Get("org_id") != "" {
-				middleware.BearerAuth(h.GetOrganization)(w, r)
+				withTimeout(middleware.RequireScope("admin:*")(h.GetOrganization))(w, r)
 			} else {
-				middleware.BearerAuth(h.ListOrganizations)(w, r)
+				withTimeout(middleware.RequireScope("admin:*")(h.ListOrganizations))(w, r)
 			}
 		default:
 			sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
@@ -270,17 +519,17 @@ func (h *Handlers) RegisterRoutes(mux *http.ServeMux, nats *embeddednats.Embedde
 	mux.HandleFunc("/api/v1/entities", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			middleware.BearerAuth(h.CreateEntity)(w, r)
+			withTimeout(middleware.RequireScope("entities:write")(h.CreateEntity))(w, r)
 		case http.MethodGet:
 			if r.URL.Query().Get("entity_id") != "" {
-				middleware.BearerAuth(h.GetEntity)(w, r)
+				withTimeout(middleware.RequireScope("entities:read")(h.GetEntity))(w, r)
 			} else {
-				middleware.BearerAuth(h.ListEntities)(w, r)
+				withTimeout(middleware.RequireScope("entities:read")(h.ListEntities))(w, r)
 			}
 		case http.MethodPut:
-			middleware.BearerAuth(h.UpdateEntity)(w, r)
+			withTimeout(middleware.RequireScope("entities:write")(h.UpdateEntity))(w, r)
 		case http.MethodDelete:
-			middleware.BearerAuth(h.DeleteEntity)(w, r)
+			withTimeout(middleware.RequireScope("entities:write")(h.DeleteEntity))(w, r)
 		default:
 			sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
 		}
@@ -3,63 +3,70 @@ package middleware
 import (
 	"constellation-api/pkg/shared"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
-	"strings"
 )
 
-// BearerAuth middleware for API authentication
-func BearerAuth(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Get the bearer token from environment or use default
-		validToken := os.Getenv("API_BEARER_TOKEN")
-		if validToken == "" {
-			validToken = "constellation-dev-token" // Default for development
-		}
+// RequireScope returns middleware that authenticates the request via the
+// package's configured Authenticator (static dev token or JWT/JWKS,
+// see NewAuthenticatorFromEnv), rejects it unless the resulting Principal
+// holds scope, and otherwise attaches the Principal to the request context
+// so handlers can further restrict it to the org(s) the caller may act
+// within (see Principal.HasOrg).
+func RequireScope(scope string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, err := getDefaultAuthenticator().Authenticate(r)
+			if err != nil {
+				sendUnauthorized(w, err.Error())
+				return
+			}
 
-		// Extract token from Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			sendUnauthorized(w, "Missing authorization header")
-			return
+			if !principal.HasScope(scope) {
+				sendForbidden(w, fmt.Sprintf("missing required scope: %s", scope))
+				return
+			}
+
+			next(w, r.WithContext(contextWithPrincipal(r.Context(), principal)))
 		}
+	}
+}
 
-		// Check if it's a bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			sendUnauthorized(w, "Invalid authorization format")
+// AdminAuth guards the operator-facing /debug/nats/* endpoints with their own
+// bearer token (ADMIN_BEARER_TOKEN), checked independently of the package's
+// configured Authenticator. It must stay independent: StaticTokenAuthenticator
+// grants every API_BEARER_TOKEN holder the admin:* scope so RequireScope
+// works without per-caller claims in dev mode, which would otherwise hand
+// every tenant API caller JetStream stream purge and consumer reset too.
+func AdminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := extractToken(r)
+		if err != nil {
+			sendUnauthorized(w, err.Error())
 			return
 		}
-
-		token := parts[1]
-		if token != validToken {
-			sendUnauthorized(w, "Invalid token")
+		if token != adminToken() {
+			sendUnauthorized(w, "invalid admin token")
 			return
 		}
-
-		// Token is valid, proceed with the request
 		next(w, r)
 	}
 }
 
-// OptionalAuth middleware - allows both authenticated and unauthenticated requests
-func OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader != "" {
-			// If auth header is present, validate it
-			BearerAuth(next)(w, r)
-		} else {
-			// No auth header, proceed anyway
-			next(w, r)
-		}
+// adminToken returns the credential AdminAuth checks against, from
+// ADMIN_BEARER_TOKEN, falling back to a fixed development default.
+func adminToken() string {
+	if token := os.Getenv("ADMIN_BEARER_TOKEN"); token != "" {
+		return token
 	}
+	return "constellation-admin-token" // Default for development
 }
 
 func sendUnauthorized(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
-	
+
 	response := shared.Response{
 		Success: false,
 		Error: &shared.Error{
@@ -67,7 +74,22 @@ func sendUnauthorized(w http.ResponseWriter, message string) {
 			Message: message,
 		},
 	}
-	
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func sendForbidden(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+
+	response := shared.Response{
+		Success: false,
+		Error: &shared.Error{
+			Code:    "FORBIDDEN",
+			Message: message,
+		},
+	}
+
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -97,4 +119,4 @@ func RequestLogger(next http.Handler) http.Handler {
 		// In production, you'd want more sophisticated logging
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
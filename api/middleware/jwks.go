@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+)
+
+// jwk is a single JSON Web Key as published by a JWKS endpoint. Only the
+// fields needed to reconstruct RSA and EC public keys are modeled; everything
+// else in the document is ignored.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksDocument is a fetched and parsed JWKS key set, indexed by kid for fast
+// lookup during token verification.
+type jwksDocument struct {
+	keys map[string]interface{}
+}
+
+// key returns the public key for kid, if present.
+func (d *jwksDocument) key(kid string) (interface{}, bool) {
+	key, ok := d.keys[kid]
+	return key, ok
+}
+
+// fetchJWKS retrieves and parses the JWKS document published at
+// "<issuerURL>/.well-known/jwks.json".
+func fetchJWKS(client *http.Client, issuerURL string) (*jwksDocument, error) {
+	resp, err := client.Get(issuerURL + "/.well-known/jwks.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	doc := &jwksDocument{keys: make(map[string]interface{}, len(body.Keys))}
+	for _, k := range body.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue // skip keys we don't support (e.g. unrecognized kty) rather than failing the whole set
+		}
+		doc.keys[k.Kid] = key
+	}
+
+	return doc, nil
+}
+
+// publicKey reconstructs the Go crypto key a jwk describes.
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64urlBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := base64urlBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := ellipticCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := base64urlBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := base64urlBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func ellipticCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported EC curve: %s", crv)
+	}
+}
+
+func base64urlBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Principal identifies the caller an Authenticator resolved a request to: who
+// they are, which organizations they may act within, and which scopes they
+// hold.
+type Principal struct {
+	Subject string
+	OrgIDs  []string
+	Scopes  []string
+}
+
+// orgWildcard is the sentinel OrgIDs entry meaning "every organization",
+// used by the dev-mode static token so it keeps working without per-org
+// claims.
+const orgWildcard = "*"
+
+// scopeWildcard grants every scope, e.g. admin:*.
+const scopeWildcard = "admin:*"
+
+// HasOrg reports whether p may act within orgID.
+func (p *Principal) HasOrg(orgID string) bool {
+	for _, id := range p.OrgIDs {
+		if id == orgWildcard || id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether p holds scope, either exactly, via the admin:*
+// wildcard, or via a "<resource>:*" wildcard covering it.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == scopeWildcard {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(s, "*"); ok && strings.HasPrefix(scope, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalContextKey struct{}
+
+// contextWithPrincipal attaches p to ctx for downstream handlers.
+func contextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext retrieves the Principal a RequireScope middleware
+// attached to the request context, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok
+}
+
+// PrincipalFromRequest is a convenience wrapper around
+// PrincipalFromContext(r.Context()).
+func PrincipalFromRequest(r *http.Request) (*Principal, bool) {
+	return PrincipalFromContext(r.Context())
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultRequestTimeout bounds how long a non-streaming handler may run
+// before its context is canceled, so a stalled NATS publish or DB call can't
+// pin an HTTP goroutine indefinitely.
+const DefaultRequestTimeout = 15 * time.Second
+
+// RequestTimeout wraps next so r.Context() carries a deadline timeout from
+// now, on top of the cancellation the request context already carries if the
+// client disconnects first. Handlers and the *Service methods they call
+// should select on ctx.Done() (or pass it straight to QueryContext/
+// ExecContext/PublishWithDedup) to abort promptly rather than running to
+// completion against a client that's gone.
+func RequestTimeout(timeout time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
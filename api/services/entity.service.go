@@ -3,7 +3,10 @@ package services
 import (
 	"constellation-api/pkg/ontology"
 	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+	"constellation-api/pkg/shared/httperr"
 	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -13,20 +16,82 @@ import (
 	"github.com/google/uuid"
 )
 
+// maxUpdateRetries bounds the read-apply-CAS retry loop in UpdateEntity so a
+// hot entity can't spin a request forever under contention.
+const maxUpdateRetries = 3
+
+// eventPublishTimeout bounds the fire-and-forget NATS publish
+// (publishEntityEvent) that the CRUD methods issue in its own goroutine once
+// their write has committed. It runs detached from the
+// originating request's context (which may already be canceled by the time
+// the publish happens) but still shouldn't be allowed to block forever.
+const eventPublishTimeout = 5 * time.Second
+
 type EntityService struct {
 	db   *sql.DB
 	nats *embeddednats.EmbeddedNATS
+
+	hooks map[HookEvent][]EntityHook
 }
 
 func NewEntityService(db *sql.DB, nats *embeddednats.EmbeddedNATS) *EntityService {
-	return &EntityService{
-		db:   db,
-		nats: nats,
+	s := &EntityService{
+		db:    db,
+		nats:  nats,
+		hooks: make(map[HookEvent][]EntityHook),
+	}
+
+	// Register the built-in natsPublishHook as an ordinary post-hook. Its
+	// methods are no-ops: the NATS publish itself happens in CreateEntity,
+	// UpdateEntity and DeleteEntity after their transaction commits, not
+	// through the hook chain. Further publishers (Kafka, a webhook) added via
+	// RegisterHook run inside the transaction, before that commit.
+	publish := &natsPublishHook{svc: s}
+	s.RegisterHook(EventPostCreate, publish)
+	s.RegisterHook(EventPostUpdate, publish)
+	s.RegisterHook(EventPostDelete, publish)
+
+	return s
+}
+
+// RegisterHook adds hook to the set run for event, in registration order,
+// inside the same transaction as the triggering CRUD write.
+func (s *EntityService) RegisterHook(event HookEvent, hook EntityHook) {
+	s.hooks[event] = append(s.hooks[event], hook)
+}
+
+// runHooks invokes every hook registered for event against entity, inside
+// tx. The first error aborts the remaining hooks and is returned so the
+// caller rolls back.
+func (s *EntityService) runHooks(ctx context.Context, tx *sql.Tx, event HookEvent, entity *ontology.Entity) error {
+	for _, hook := range s.hooks[event] {
+		var err error
+		switch event {
+		case EventPreCreate:
+			err = hook.PreCreate(ctx, tx, entity)
+		case EventPostCreate:
+			err = hook.PostCreate(ctx, tx, entity)
+		case EventPreUpdate:
+			err = hook.PreUpdate(ctx, tx, entity)
+		case EventPostUpdate:
+			err = hook.PostUpdate(ctx, tx, entity)
+		case EventPreDelete:
+			err = hook.PreDelete(ctx, tx, entity)
+		case EventPostDelete:
+			err = hook.PostDelete(ctx, tx, entity)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook failed: %w", event, err)
+		}
 	}
+	return nil
 }
 
-func (s *EntityService) CreateEntity(orgID string, req *ontology.CreateEntityRequest) (*ontology.Entity, error) {
-	entityID := uuid.New().String()
+func (s *EntityService) CreateEntity(ctx context.Context, orgID string, req *ontology.CreateEntityRequest) (*ontology.Entity, error) {
+	if req.EntityType == "" {
+		return nil, httperr.ErrValidation("entity_type is required").WithDetails("entity_type")
+	}
+
 	now := time.Now()
 
 	// Set defaults
@@ -46,55 +111,75 @@ func (s *EntityService) CreateEntity(orgID string, req *ontology.CreateEntityReq
 		metadataJSON = string(bytes)
 	}
 
-	var latitude, longitude, altitude interface{}
+	entity := &ontology.Entity{
+		EntityID:        uuid.New().String(),
+		OrgID:           orgID,
+		EntityType:      req.EntityType,
+		Status:          status,
+		Priority:        priority,
+		Metadata:        metadataJSON,
+		ResourceVersion: 1,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
 	if req.Position != nil {
-		latitude = req.Position.Latitude
-		longitude = req.Position.Longitude
+		entity.Latitude = &req.Position.Latitude
+		entity.Longitude = &req.Position.Longitude
 		if req.Position.Altitude != 0 {
-			altitude = req.Position.Altitude
+			entity.Altitude = &req.Position.Altitude
 		}
 	}
 
-	_, err := s.db.Exec(
-		`INSERT INTO entities (entity_id, org_id, entity_type, status, priority, latitude, longitude, altitude, metadata, created_at, updated_at) 
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entityID, orgID, req.EntityType, status, priority, latitude, longitude, altitude, metadataJSON, 
-		now.Format(time.RFC3339), now.Format(time.RFC3339),
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.runHooks(ctx, tx, EventPreCreate, entity); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO entities (entity_id, org_id, entity_type, status, priority, latitude, longitude, altitude, metadata, resource_version, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entity.EntityID, entity.OrgID, entity.EntityType, entity.Status, entity.Priority,
+		nullableFloat(entity.Latitude), nullableFloat(entity.Longitude), nullableFloat(entity.Altitude), entity.Metadata,
+		entity.ResourceVersion, now.Format(time.RFC3339), now.Format(time.RFC3339),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create entity: %w", err)
 	}
 
-	entity := &ontology.Entity{
-		EntityID:   entityID,
-		OrgID:      orgID,
-		EntityType: req.EntityType,
-		Status:     status,
-		Priority:   priority,
-		Metadata:   metadataJSON,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+	if err := s.runHooks(ctx, tx, EventPostCreate, entity); err != nil {
+		return nil, err
 	}
 
-	if req.Position != nil {
-		entity.Latitude = &req.Position.Latitude
-		entity.Longitude = &req.Position.Longitude
-		if req.Position.Altitude != 0 {
-			entity.Altitude = &req.Position.Altitude
-		}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Publish entity created event
+	// Publish entity created event only now that the transaction has
+	// actually committed - see natsPublishHook.PostCreate.
 	go s.publishEntityEvent(entity, shared.EventTypeCreated)
 
 	return entity, nil
 }
 
-func (s *EntityService) ListEntities(orgID string) ([]ontology.Entity, error) {
-	rows, err := s.db.Query(
-		`SELECT entity_id, org_id, entity_type, status, priority, is_live, 
-		        latitude, longitude, altitude, heading, velocity, 
-		        components, tags, metadata, created_at, updated_at 
+// nullableFloat converts an optional float field to a value database/sql can
+// bind, preserving NULL when unset.
+func nullableFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func (s *EntityService) ListEntities(ctx context.Context, orgID string) ([]ontology.Entity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT entity_id, org_id, entity_type, status, priority, is_live,
+		        latitude, longitude, altitude, heading, velocity,
+		        components, tags, metadata, resource_version, created_at, updated_at
 		 FROM entities WHERE org_id = ?`, orgID,
 	)
 	if err != nil {
@@ -114,18 +199,24 @@ func (s *EntityService) ListEntities(orgID string) ([]ontology.Entity, error) {
 	return entities, nil
 }
 
-func (s *EntityService) GetEntity(orgID, entityID string) (*ontology.Entity, error) {
-	row := s.db.QueryRow(
-		`SELECT entity_id, org_id, entity_type, status, priority, is_live, 
-		        latitude, longitude, altitude, heading, velocity, 
-		        components, tags, metadata, created_at, updated_at 
-		 FROM entities WHERE org_id = ? AND entity_id = ?`, 
+// sqlRowQuerier is satisfied by both *sql.DB and *sql.Tx, letting getEntity
+// read either outside or inside a transaction.
+type sqlRowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *EntityService) getEntity(ctx context.Context, q sqlRowQuerier, orgID, entityID string) (*ontology.Entity, error) {
+	row := q.QueryRowContext(ctx,
+		`SELECT entity_id, org_id, entity_type, status, priority, is_live,
+		        latitude, longitude, altitude, heading, velocity,
+		        components, tags, metadata, resource_version, created_at, updated_at
+		 FROM entities WHERE org_id = ? AND entity_id = ?`,
 		orgID, entityID,
 	)
 
 	entity, err := s.scanEntity(row)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("entity not found")
+		return nil, httperr.ErrNotFound("entity not found")
 	}
 	if err != nil {
 		return nil, err
@@ -134,13 +225,142 @@ func (s *EntityService) GetEntity(orgID, entityID string) (*ontology.Entity, err
 	return entity, nil
 }
 
-func (s *EntityService) UpdateEntity(orgID, entityID string, updates map[string]interface{}) (*ontology.Entity, error) {
+func (s *EntityService) GetEntity(ctx context.Context, orgID, entityID string) (*ontology.Entity, error) {
+	return s.getEntity(ctx, s.db, orgID, entityID)
+}
+
+// UpdateEntity applies updates to an entity using optimistic concurrency
+// control. The caller's expected ResourceVersion is taken from
+// ifMatchVersion if non-nil, else from an "expected_version" key in updates
+// (removed before the update is built) — whichever is supplied must match
+// the stored version or an httperr.ErrVersionConflict is returned
+// immediately (no retry). Without either, the method performs a bounded
+// read -> apply-mutation -> CAS-update loop: it re-reads the current row and
+// version, builds the update against that version, and issues
+// `UPDATE ... WHERE entity_id = ? AND resource_version = ?` inside a
+// transaction that also runs the pre/post update hooks. If the CAS affects
+// zero rows (a concurrent writer won the race), it rolls back, re-reads, and
+// retries up to maxUpdateRetries times before giving up with an
+// httperr.ErrVersionConflict. ctx bounds every read/write and is also
+// checked between retries so a canceled or expired request aborts promptly
+// instead of spinning through the remaining attempts.
+func (s *EntityService) UpdateEntity(ctx context.Context, orgID, entityID string, updates map[string]interface{}, ifMatchVersion *int64) (*ontology.Entity, error) {
 	if len(updates) == 0 {
 		return nil, fmt.Errorf("no updates provided")
 	}
 
-	// Build dynamic update query
-	query := "UPDATE entities SET updated_at = ?"
+	if ifMatchVersion == nil {
+		if raw, ok := updates["expected_version"]; ok {
+			delete(updates, "expected_version")
+			v, err := toInt64(raw)
+			if err != nil {
+				return nil, httperr.ErrValidation("expected_version must be a number").WithCause(err)
+			}
+			ifMatchVersion = &v
+		}
+	}
+
+	for attempt := 0; attempt < maxUpdateRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entity, retry, err := s.updateEntityOnce(ctx, orgID, entityID, updates, ifMatchVersion)
+		if err != nil {
+			return nil, err
+		}
+		if retry {
+			// Someone else updated (or deleted) the row between our read and
+			// our write; re-read the latest state and try again.
+			continue
+		}
+
+		// Publish entity updated event
+		go s.publishEntityEvent(entity, shared.EventTypeUpdated)
+
+		return entity, nil
+	}
+
+	return nil, httperr.ErrVersionConflict("entity update conflict: exceeded retry attempts")
+}
+
+// toInt64 coerces a JSON-decoded numeric value (float64) or a plain int64
+// into int64, for expected_version values arriving either from a decoded
+// request body or from a caller constructing the updates map directly.
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// updateEntityOnce runs a single read-apply-CAS attempt inside its own
+// transaction. retry is true when the CAS lost the race and the caller
+// should try again with a fresh read.
+func (s *EntityService) updateEntityOnce(ctx context.Context, orgID, entityID string, updates map[string]interface{}, ifMatchVersion *int64) (entity *ontology.Entity, retry bool, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := s.getEntity(ctx, tx, orgID, entityID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ifMatchVersion != nil && *ifMatchVersion != current.ResourceVersion {
+		return nil, false, httperr.ErrVersionConflict(fmt.Sprintf(
+			"resource version mismatch: expected %d, current version is %d",
+			*ifMatchVersion, current.ResourceVersion,
+		))
+	}
+
+	if err := s.runHooks(ctx, tx, EventPreUpdate, current); err != nil {
+		return nil, false, err
+	}
+
+	query, args := buildEntityUpdateQuery(updates)
+	query += " WHERE org_id = ? AND entity_id = ? AND resource_version = ?"
+	args = append(args, orgID, entityID, current.ResourceVersion)
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update entity: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, true, nil
+	}
+
+	updated, err := s.getEntity(ctx, tx, orgID, entityID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := s.runHooks(ctx, tx, EventPostUpdate, updated); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, false, nil
+}
+
+// buildEntityUpdateQuery builds the dynamic SET clause for an entity update,
+// always bumping resource_version so the caller's CAS WHERE clause can pin
+// the previous value.
+func buildEntityUpdateQuery(updates map[string]interface{}) (string, []interface{}) {
+	query := "UPDATE entities SET updated_at = ?, resource_version = resource_version + 1"
 	args := []interface{}{time.Now().Format(time.RFC3339)}
 
 	for key, value := range updates {
@@ -167,40 +387,28 @@ func (s *EntityService) UpdateEntity(orgID, entityID string, updates map[string]
 		}
 	}
 
-	query += " WHERE org_id = ? AND entity_id = ?"
-	args = append(args, orgID, entityID)
+	return query, args
+}
 
-	result, err := s.db.Exec(query, args...)
+func (s *EntityService) DeleteEntity(ctx context.Context, orgID, entityID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update entity: %w", err)
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("entity not found")
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Get updated entity
-	entity, err := s.GetEntity(orgID, entityID)
+	// Get entity before deletion for hooks/event
+	entity, err := s.getEntity(ctx, tx, orgID, entityID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Publish entity updated event
-	go s.publishEntityEvent(entity, shared.EventTypeUpdated)
-
-	return entity, nil
-}
-
-func (s *EntityService) DeleteEntity(orgID, entityID string) error {
-	// Get entity before deletion for event
-	entity, err := s.GetEntity(orgID, entityID)
-	if err != nil {
+	if err := s.runHooks(ctx, tx, EventPreDelete, entity); err != nil {
 		return err
 	}
 
-	result, err := s.db.Exec(
-		"DELETE FROM entities WHERE org_id = ? AND entity_id = ?", 
+	result, err := tx.ExecContext(ctx,
+		"DELETE FROM entities WHERE org_id = ? AND entity_id = ?",
 		orgID, entityID,
 	)
 	if err != nil {
@@ -209,21 +417,30 @@ func (s *EntityService) DeleteEntity(orgID, entityID string) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("entity not found")
+		return httperr.ErrNotFound("entity not found")
 	}
 
-	// Publish entity deleted event
+	if err := s.runHooks(ctx, tx, EventPostDelete, entity); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Publish entity deleted event only now that the transaction has
+	// actually committed - see natsPublishHook.PostDelete.
 	go s.publishEntityEvent(entity, shared.EventTypeDeleted)
 
 	return nil
 }
 
-func (s *EntityService) UpdateEntityStatus(orgID, entityID, status string) error {
+func (s *EntityService) UpdateEntityStatus(ctx context.Context, orgID, entityID, status string) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
-	
-	entity, err := s.UpdateEntity(orgID, entityID, updates)
+
+	entity, err := s.UpdateEntity(ctx, orgID, entityID, updates, nil)
 	if err != nil {
 		return err
 	}
@@ -234,44 +451,90 @@ func (s *EntityService) UpdateEntityStatus(orgID, entityID, status string) error
 	return nil
 }
 
+// natsPublishHook is the built-in EntityHook NewEntityService registers for
+// the post-create/update/delete events. It publishes nothing itself: each
+// method returns nil, and the CRUD methods publish their NATS events once
+// the transaction has committed (see publishEntityEvent).
+type natsPublishHook struct {
+	EntityHookBase
+	svc *EntityService
+}
+
+func (h *natsPublishHook) PostCreate(_ context.Context, _ *sql.Tx, entity *ontology.Entity) error {
+	// CreateEntity publishes EventTypeCreated itself once tx.Commit succeeds,
+	// since this hook runs inside the transaction - publishing here would
+	// let a subscriber observe a create the transaction then fails to commit.
+	return nil
+}
+
+func (h *natsPublishHook) PostUpdate(_ context.Context, _ *sql.Tx, entity *ontology.Entity) error {
+	// UpdateEntity publishes EventTypeUpdated itself once its CAS retry loop
+	// settles on a final version, since updateEntityOnce's per-attempt
+	// transaction may be rolled back and retried before that happens.
+	return nil
+}
+
+func (h *natsPublishHook) PostDelete(_ context.Context, _ *sql.Tx, entity *ontology.Entity) error {
+	// DeleteEntity publishes EventTypeDeleted itself once tx.Commit succeeds,
+	// for the same reason as PostCreate above.
+	return nil
+}
+
+// entitySubject routes eventType to the JetStream subject its listeners
+// filter on; the CloudEvents envelope attributes below (Source/Type/Subject)
+// identify what happened, this is just where it's delivered.
+func entitySubject(orgID, eventType string) string {
+	switch eventType {
+	case shared.EventTypeUpdated:
+		return shared.EntityUpdatedSubject(orgID)
+	case shared.EventTypeDeleted:
+		return shared.EntityDeletedSubject(orgID)
+	case shared.EventTypeStatus:
+		return shared.EntityStatusSubject(orgID)
+	default:
+		return shared.EntityCreatedSubject(orgID)
+	}
+}
+
+// publishEntityEvent runs in its own goroutine, detached from the request
+// that triggered it, so it uses its own bounded context rather than the
+// (possibly already-canceled) request context.
 func (s *EntityService) publishEntityEvent(entity *ontology.Entity, eventType string) {
 	if s.nats == nil || s.nats.JetStream() == nil {
 		log.Printf("NATS not available for publishing event")
 		return
 	}
 
-	event := shared.Event{
-		ID:      uuid.New().String(),
-		Type:    eventType,
-		Subject: shared.EntityCreatedSubject(entity.OrgID),
-		Data: map[string]interface{}{
-			"entity_id":   entity.EntityID,
-			"org_id":      entity.OrgID,
-			"entity_type": entity.EntityType,
-			"status":      entity.Status,
-			"priority":    entity.Priority,
-		},
-		Timestamp: time.Now().UTC(),
-		Source:    "entity-service",
+	data := map[string]interface{}{
+		"entity_id":        entity.EntityID,
+		"org_id":           entity.OrgID,
+		"entity_type":      entity.EntityType,
+		"status":           entity.Status,
+		"priority":         entity.Priority,
+		"resource_version": entity.ResourceVersion,
 	}
-
 	// Add full entity data for create/update events
 	if eventType == shared.EventTypeCreated || eventType == shared.EventTypeUpdated {
-		event.Data["entity"] = entity
+		data["entity"] = entity
 	}
 
-	data, err := json.Marshal(event)
+	source := fmt.Sprintf("urn:constellation:org:%s:entity:%s", entity.OrgID, entity.EntityID)
+	event, err := cloudevents.New(uuid.New().String(), source, "com.constellation.entity."+eventType, entity.EntityID, data)
 	if err != nil {
-		log.Printf("Failed to marshal entity event: %v", err)
+		log.Printf("Failed to build entity cloudevent: %v", err)
 		return
 	}
 
+	subject := entitySubject(entity.OrgID, eventType)
 	msgID := fmt.Sprintf("%s-%s-%d", entity.EntityID, eventType, time.Now().UnixNano())
 
-	if err := s.nats.PublishWithDedup(event.Subject, data, msgID); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+
+	if err := s.nats.PublishEventWithDedup(ctx, subject, event, cloudevents.ModeStructured, msgID); err != nil {
 		log.Printf("Failed to publish entity event: %v", err)
 	} else {
-		log.Printf("Published entity event: %s on subject: %s", eventType, event.Subject)
+		log.Printf("Published entity event: %s on subject: %s", eventType, subject)
 	}
 }
 
@@ -284,7 +547,7 @@ func (s *EntityService) scanEntity(scanner interface{ Scan(...interface{}) error
 	err := scanner.Scan(
 		&entity.EntityID, &entity.OrgID, &entity.EntityType, &entity.Status, &entity.Priority,
 		&isLive, &lat, &lon, &alt, &heading, &velocity,
-		&entity.Components, &entity.Tags, &entity.Metadata, &createdAt, &updatedAt,
+		&entity.Components, &entity.Tags, &entity.Metadata, &entity.ResourceVersion, &createdAt, &updatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan entity: %w", err)
@@ -311,4 +574,4 @@ func (s *EntityService) scanEntity(scanner interface{ Scan(...interface{}) error
 	entity.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 
 	return &entity, nil
-}
\ No newline at end of file
+}
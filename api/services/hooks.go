@@ -0,0 +1,85 @@
+package services
+
+import (
+	"constellation-api/pkg/ontology"
+	"context"
+	"database/sql"
+)
+
+// HookEvent names a point in an EntityService or OrganizationService CRUD
+// method where registered hooks run, inside the same *sql.Tx as the
+// triggering write. A hook returning an error rolls back that transaction -
+// the DB write and any earlier hooks for the same event included.
+type HookEvent string
+
+const (
+	EventPreCreate  HookEvent = "pre_create"
+	EventPostCreate HookEvent = "post_create"
+	EventPreUpdate  HookEvent = "pre_update"
+	EventPostUpdate HookEvent = "post_update"
+	EventPreDelete  HookEvent = "pre_delete"
+	EventPostDelete HookEvent = "post_delete"
+)
+
+// EntityHook lets external code participate in EntityService's CRUD methods:
+// validation, enrichment (e.g. reverse-geocoding a position, computing
+// derived tags), or publishing to additional sinks (Kafka, a webhook). The
+// built-in NATS publish does not run through these hooks: NewEntityService
+// registers a no-op natsPublishHook, and the CRUD methods publish after
+// their transaction commits.
+//
+// Embed EntityHookBase to implement only the method(s) a given hook cares
+// about; the rest are no-ops.
+type EntityHook interface {
+	PreCreate(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+	PostCreate(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+	PreUpdate(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+	PostUpdate(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+	PreDelete(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+	PostDelete(ctx context.Context, tx *sql.Tx, entity *ontology.Entity) error
+}
+
+// EntityHookBase is a no-op EntityHook meant to be embedded.
+type EntityHookBase struct{}
+
+func (EntityHookBase) PreCreate(context.Context, *sql.Tx, *ontology.Entity) error  { return nil }
+func (EntityHookBase) PostCreate(context.Context, *sql.Tx, *ontology.Entity) error { return nil }
+func (EntityHookBase) PreUpdate(context.Context, *sql.Tx, *ontology.Entity) error  { return nil }
+func (EntityHookBase) PostUpdate(context.Context, *sql.Tx, *ontology.Entity) error { return nil }
+func (EntityHookBase) PreDelete(context.Context, *sql.Tx, *ontology.Entity) error  { return nil }
+func (EntityHookBase) PostDelete(context.Context, *sql.Tx, *ontology.Entity) error { return nil }
+
+// OrganizationHook is OrganizationService's equivalent of EntityHook.
+//
+// Embed OrganizationHookBase to implement only the method(s) a given hook
+// cares about; the rest are no-ops.
+type OrganizationHook interface {
+	PreCreate(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+	PostCreate(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+	PreUpdate(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+	PostUpdate(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+	PreDelete(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+	PostDelete(ctx context.Context, tx *sql.Tx, org *ontology.Organization) error
+}
+
+// OrganizationHookBase is a no-op OrganizationHook meant to be embedded.
+type OrganizationHookBase struct{}
+
+func (OrganizationHookBase) PreCreate(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
+func (OrganizationHookBase) PostCreate(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
+func (OrganizationHookBase) PreUpdate(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
+func (OrganizationHookBase) PostUpdate(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
+func (OrganizationHookBase) PreDelete(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
+func (OrganizationHookBase) PostDelete(context.Context, *sql.Tx, *ontology.Organization) error {
+	return nil
+}
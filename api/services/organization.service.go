@@ -2,6 +2,8 @@ package services
 
 import (
 	"constellation-api/pkg/ontology"
+	"constellation-api/pkg/shared/httperr"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,6 +14,8 @@ import (
 
 type OrganizationService struct {
 	db *sql.DB
+
+	hooks map[HookEvent][]OrganizationHook
 }
 
 func (s *OrganizationService) DB() *sql.DB {
@@ -19,10 +23,46 @@ func (s *OrganizationService) DB() *sql.DB {
 }
 
 func NewOrganizationService(db *sql.DB) *OrganizationService {
-	return &OrganizationService{db: db}
+	return &OrganizationService{
+		db:    db,
+		hooks: make(map[HookEvent][]OrganizationHook),
+	}
 }
 
-func (s *OrganizationService) CreateOrganization(req *ontology.CreateOrganizationRequest) (*ontology.Organization, error) {
+// RegisterHook adds hook to the set run for event, in registration order,
+// inside the same transaction as the triggering CRUD write.
+func (s *OrganizationService) RegisterHook(event HookEvent, hook OrganizationHook) {
+	s.hooks[event] = append(s.hooks[event], hook)
+}
+
+// runHooks invokes every hook registered for event against org, inside tx.
+// The first error aborts the remaining hooks and is returned so the caller
+// rolls back.
+func (s *OrganizationService) runHooks(ctx context.Context, tx *sql.Tx, event HookEvent, org *ontology.Organization) error {
+	for _, hook := range s.hooks[event] {
+		var err error
+		switch event {
+		case EventPreCreate:
+			err = hook.PreCreate(ctx, tx, org)
+		case EventPostCreate:
+			err = hook.PostCreate(ctx, tx, org)
+		case EventPreUpdate:
+			err = hook.PreUpdate(ctx, tx, org)
+		case EventPostUpdate:
+			err = hook.PostUpdate(ctx, tx, org)
+		case EventPreDelete:
+			err = hook.PreDelete(ctx, tx, org)
+		case EventPostDelete:
+			err = hook.PostDelete(ctx, tx, org)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook failed: %w", event, err)
+		}
+	}
+	return nil
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, req *ontology.CreateOrganizationRequest) (*ontology.Organization, error) {
 	orgID := uuid.New().String()
 	now := time.Now()
 
@@ -32,27 +72,47 @@ func (s *OrganizationService) CreateOrganization(req *ontology.CreateOrganizatio
 		metadataJSON = string(bytes)
 	}
 
-	_, err := s.db.Exec(
-		`INSERT INTO organizations (org_id, name, org_type, metadata, created_at, updated_at) 
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		orgID, req.Name, req.OrgType, metadataJSON, now.Format(time.RFC3339), now.Format(time.RFC3339),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create organization: %w", err)
-	}
-
-	return &ontology.Organization{
+	org := &ontology.Organization{
 		OrgID:     orgID,
 		Name:      req.Name,
 		OrgType:   req.OrgType,
 		Metadata:  metadataJSON,
 		CreatedAt: now,
 		UpdatedAt: now,
-	}, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.runHooks(ctx, tx, EventPreCreate, org); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO organizations (org_id, name, org_type, metadata, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		org.OrgID, org.Name, org.OrgType, org.Metadata, now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	if err := s.runHooks(ctx, tx, EventPostCreate, org); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return org, nil
 }
 
-func (s *OrganizationService) ListOrganizations() ([]ontology.Organization, error) {
-	rows, err := s.db.Query(
+func (s *OrganizationService) ListOrganizations(ctx context.Context) ([]ontology.Organization, error) {
+	rows, err := s.db.QueryContext(ctx,
 		`SELECT org_id, name, org_type, metadata, created_at, updated_at FROM organizations`,
 	)
 	if err != nil {
@@ -64,12 +124,12 @@ func (s *OrganizationService) ListOrganizations() ([]ontology.Organization, erro
 	for rows.Next() {
 		var org ontology.Organization
 		var createdAt, updatedAt string
-		
+
 		err := rows.Scan(&org.OrgID, &org.Name, &org.OrgType, &org.Metadata, &createdAt, &updatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan organization: %w", err)
 		}
-		
+
 		org.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 		org.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 		orgs = append(orgs, org)
@@ -78,18 +138,24 @@ func (s *OrganizationService) ListOrganizations() ([]ontology.Organization, erro
 	return orgs, nil
 }
 
-func (s *OrganizationService) GetOrganization(orgID string) (*ontology.Organization, error) {
+// orgRowQuerier is satisfied by both *sql.DB and *sql.Tx, letting getOrganization
+// read either outside or inside a transaction.
+type orgRowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *OrganizationService) getOrganization(ctx context.Context, q orgRowQuerier, orgID string) (*ontology.Organization, error) {
 	var org ontology.Organization
 	var createdAt, updatedAt string
 
-	err := s.db.QueryRow(
-		`SELECT org_id, name, org_type, metadata, created_at, updated_at 
+	err := q.QueryRowContext(ctx,
+		`SELECT org_id, name, org_type, metadata, created_at, updated_at
 		 FROM organizations WHERE org_id = ?`,
 		orgID,
 	).Scan(&org.OrgID, &org.Name, &org.OrgType, &org.Metadata, &createdAt, &updatedAt)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("organization not found")
+		return nil, httperr.ErrNotFound("organization not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query organization: %w", err)
@@ -101,11 +167,30 @@ func (s *OrganizationService) GetOrganization(orgID string) (*ontology.Organizat
 	return &org, nil
 }
 
-func (s *OrganizationService) UpdateOrganization(orgID string, updates map[string]interface{}) error {
+func (s *OrganizationService) GetOrganization(ctx context.Context, orgID string) (*ontology.Organization, error) {
+	return s.getOrganization(ctx, s.db, orgID)
+}
+
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, orgID string, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
 	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	org, err := s.getOrganization(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.runHooks(ctx, tx, EventPreUpdate, org); err != nil {
+		return err
+	}
+
 	// Build dynamic update query
 	query := "UPDATE organizations SET updated_at = ? "
 	args := []interface{}{time.Now().Format(time.RFC3339)}
@@ -125,29 +210,57 @@ func (s *OrganizationService) UpdateOrganization(orgID string, updates map[strin
 	query += " WHERE org_id = ?"
 	args = append(args, orgID)
 
-	result, err := s.db.Exec(query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update organization: %w", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("organization not found")
+		return httperr.ErrNotFound("organization not found")
 	}
 
-	return nil
+	updated, err := s.getOrganization(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.runHooks(ctx, tx, EventPostUpdate, updated); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *OrganizationService) DeleteOrganization(orgID string) error {
-	result, err := s.db.Exec("DELETE FROM organizations WHERE org_id = ?", orgID)
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, orgID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	org, err := s.getOrganization(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.runHooks(ctx, tx, EventPreDelete, org); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM organizations WHERE org_id = ?", orgID)
 	if err != nil {
 		return fmt.Errorf("failed to delete organization: %w", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("organization not found")
+		return httperr.ErrNotFound("organization not found")
 	}
 
-	return nil
-}
\ No newline at end of file
+	if err := s.runHooks(ctx, tx, EventPostDelete, org); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
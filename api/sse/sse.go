@@ -0,0 +1,272 @@
+// Package sse exposes the JetStream entity/telemetry/event/command streams
+// as text/event-stream endpoints, so a web UI can observe the same traffic
+// api.Stream multiplexes over WebSocket without embedding a NATS client,
+// using the plain HTTP primitive browsers already know how to resume
+// (Last-Event-ID) and reconnect on their own.
+package sse
+
+import (
+	"constellation-api/api/middleware"
+	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/services/statecache"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+	"constellation-api/pkg/shared/httperr"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// heartbeatInterval bounds how long a connection can go without a write
+// before Handlers sends an SSE comment, so intermediaries (and the browser's
+// own dead-connection detection) don't time it out during a quiet stream.
+const heartbeatInterval = 15 * time.Second
+
+const fetchBatchSize = 10
+const fetchMaxWait = 2 * time.Second
+
+// Handlers serves the /api/v1/stream/* SSE endpoints.
+type Handlers struct {
+	nats       *embeddednats.EmbeddedNATS
+	stateCache *statecache.Service
+}
+
+// NewHandlers builds the SSE Handlers. stateCache is the same instance the
+// rest of api.Handlers uses, so the entity-state snapshot/delta stream below
+// and the GET .../state endpoint never disagree about what's in the bucket.
+func NewHandlers(nats *embeddednats.EmbeddedNATS, stateCache *statecache.Service) *Handlers {
+	return &Handlers{nats: nats, stateCache: stateCache}
+}
+
+// streamRoute describes one /api/v1/stream/<name> endpoint: which JetStream
+// stream it reads from and the subject a caller's org_id is scoped to.
+type streamRoute struct {
+	streamName   string
+	orgSubjectOf func(orgID string) string
+}
+
+var (
+	entitiesRoute  = streamRoute{shared.StreamEntities, func(orgID string) string { return "constellation.entities." + orgID + ".>" }}
+	telemetryRoute = streamRoute{shared.StreamTelemetry, func(orgID string) string { return "constellation.telemetry." + orgID + ".>" }}
+	eventsRoute    = streamRoute{shared.StreamEvents, func(orgID string) string { return "constellation.events." + orgID + ".>" }}
+	commandsRoute  = streamRoute{shared.StreamCommands, func(orgID string) string { return "constellation.commands." + orgID + ".>" }}
+)
+
+// Entities serves GET /api/v1/stream/entities.
+func (h *Handlers) Entities(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, entitiesRoute)
+}
+
+// Telemetry serves GET /api/v1/stream/telemetry.
+func (h *Handlers) Telemetry(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, telemetryRoute)
+}
+
+// Events serves GET /api/v1/stream/events.
+func (h *Handlers) Events(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, eventsRoute)
+}
+
+// Commands serves GET /api/v1/stream/commands.
+func (h *Handlers) Commands(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, commandsRoute)
+}
+
+// EntitiesState serves GET /api/v1/stream/entities/state: a snapshot of
+// every entity's current state for the caller's org, followed by a live
+// delta feed, read from the entity state KV bucket rather than replaying the
+// entities stream - cheaper for a client that only cares about "what is true
+// now", not the history of how it got there.
+func (h *Handlers) EntitiesState(w http.ResponseWriter, r *http.Request) {
+	if h.stateCache == nil {
+		httperr.ErrInternal(fmt.Errorf("state cache not available")).WriteTo(w)
+		return
+	}
+
+	orgID := r.URL.Query().Get("org_id")
+	if orgID == "" {
+		httperr.ErrValidation("org_id is required").WriteTo(w)
+		return
+	}
+
+	principal, ok := middleware.PrincipalFromRequest(r)
+	if !ok || !principal.HasOrg(orgID) {
+		httperr.ErrForbidden("not a member of organization: " + orgID).WriteTo(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httperr.ErrInternal(fmt.Errorf("streaming unsupported by response writer")).WriteTo(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := h.stateCache.Watch(r.Context(), orgID, func(entry nats.KeyValueEntry) {
+		fmt.Fprintf(w, "event: state\n")
+		fmt.Fprintf(w, "id: %d\n", entry.Revision())
+		fmt.Fprintf(w, "data: %s\n\n", string(entry.Value()))
+		flusher.Flush()
+	})
+	if err != nil && err != context.Canceled {
+		fmt.Fprintf(w, ": error: %s\n\n", err)
+		flusher.Flush()
+	}
+}
+
+// serve authenticates and authorizes the request, creates an ephemeral
+// JetStream consumer scoped to route and the caller's org_id, and streams
+// matching messages to w as Server-Sent Events until r.Context() is done.
+func (h *Handlers) serve(w http.ResponseWriter, r *http.Request, route streamRoute) {
+	if h.nats == nil || h.nats.JetStream() == nil {
+		httperr.ErrInternal(fmt.Errorf("NATS not available")).WriteTo(w)
+		return
+	}
+
+	orgID := r.URL.Query().Get("org_id")
+	if orgID == "" {
+		httperr.ErrValidation("org_id is required").WriteTo(w)
+		return
+	}
+
+	principal, ok := middleware.PrincipalFromRequest(r)
+	if !ok || !principal.HasOrg(orgID) {
+		httperr.ErrForbidden("not a member of organization: " + orgID).WriteTo(w)
+		return
+	}
+
+	orgSubject := route.orgSubjectOf(orgID)
+	subject := r.URL.Query().Get("subject")
+	if subject == "" {
+		subject = orgSubject
+	} else if !strings.HasPrefix(subject, strings.TrimSuffix(orgSubject, ">")) {
+		httperr.ErrForbidden("subject must be scoped to organization: " + orgID).WriteTo(w)
+		return
+	}
+
+	deliverOpt, err := deliverOptFromCursor(r)
+	if err != nil {
+		httperr.ErrValidation(err.Error()).WriteTo(w)
+		return
+	}
+
+	sub, err := h.nats.EphemeralPullSubscribe(route.streamName, subject, deliverOpt)
+	if err != nil {
+		httperr.ErrInternal(err).WriteTo(w)
+		return
+	}
+	defer sub.Drain()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httperr.ErrInternal(fmt.Errorf("streaming unsupported by response writer")).WriteTo(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	pump(r.Context(), w, flusher, sub)
+}
+
+// deliverOptFromCursor maps the `since` query parameter and, failing that,
+// the Last-Event-ID header (set by a reconnecting EventSource) to a JetStream
+// delivery start position: a JetStream sequence if numeric, an RFC3339
+// timestamp otherwise. With neither set, delivery starts with new messages.
+func deliverOptFromCursor(r *http.Request) (nats.SubOpt, error) {
+	cursor := r.URL.Query().Get("since")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+	if cursor == "" {
+		return nats.DeliverNew(), nil
+	}
+
+	if seq, err := strconv.ParseUint(cursor, 10, 64); err == nil {
+		return nats.StartSequence(seq), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("since must be a JetStream sequence or an RFC3339 timestamp")
+	}
+	return nats.StartTime(t), nil
+}
+
+// pump fetches messages from sub until ctx is done, writing one SSE event
+// per message and a heartbeat comment on every quiet interval.
+func pump(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, sub *nats.Subscription) {
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	lastActivity := make(chan struct{}, 1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			select {
+			case <-lastActivity:
+			default:
+				fmt.Fprintf(w, ": heartbeat\n\n")
+				flusher.Flush()
+			}
+		default:
+			msgs, err := sub.Fetch(fetchBatchSize, nats.MaxWait(fetchMaxWait))
+			if err != nil {
+				if err == nats.ErrTimeout {
+					continue
+				}
+				return
+			}
+
+			for _, msg := range msgs {
+				writeEvent(w, msg)
+				select {
+				case lastActivity <- struct{}{}:
+				default:
+				}
+			}
+			if len(msgs) > 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent decodes msg's CloudEvents envelope and writes it as a single SSE
+// event: id is the JetStream sequence (so a client can resume via
+// Last-Event-ID), event is the last subject token (created/updated/deleted/
+// etc.), and data is the event's JSON data payload.
+func writeEvent(w http.ResponseWriter, msg *nats.Msg) {
+	event, err := cloudevents.Decode(msg)
+	if err != nil {
+		return
+	}
+
+	var seq uint64
+	if meta, err := msg.Metadata(); err == nil {
+		seq = meta.Sequence.Stream
+	}
+
+	tokens := strings.Split(msg.Subject, ".")
+	eventName := tokens[len(tokens)-1]
+
+	fmt.Fprintf(w, "id: %d\n", seq)
+	fmt.Fprintf(w, "event: %s\n", eventName)
+	fmt.Fprintf(w, "data: %s\n\n", string(event.Data))
+}
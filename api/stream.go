@@ -0,0 +1,265 @@
+package api
+
+import (
+	"constellation-api/api/middleware"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	streamSendBuffer = 64
+	streamHeartbeat  = 20 * time.Second
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// streamFrame is the JSON envelope multiplexed to a /api/v1/stream client.
+type streamFrame struct {
+	Type    string      `json:"type"` // "message", "warning", or "ping"
+	Stream  string      `json:"stream,omitempty"`
+	Subject string      `json:"subject,omitempty"`
+	Seq     uint64      `json:"seq,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Warning string      `json:"warning,omitempty"`
+}
+
+// streamFilter is a parsed `key=value key2=value2` expression from the
+// `filter` query parameter, e.g. "org_id=acme entity_type=drone".
+type streamFilter map[string]string
+
+func parseStreamFilter(raw string) streamFilter {
+	filter := streamFilter{}
+	for _, field := range strings.Fields(raw) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		filter[parts[0]] = parts[1]
+	}
+	return filter
+}
+
+// matches reports whether every key=value pair in the filter is satisfied by
+// the decoded message payload (the data of its CloudEvents envelope).
+func (f streamFilter) matches(payload map[string]interface{}) bool {
+	for key, want := range f {
+		got, ok := payload[key]
+		if !ok || fmt.Sprintf("%v", got) != want {
+			return false
+		}
+	}
+	return true
+}
+
+type streamSource struct {
+	name string
+	sub  *nats.Subscription
+}
+
+// Stream handles GET /api/v1/stream, upgrading to a WebSocket connection and
+// multiplexing matching messages from the entities, events, and telemetry
+// JetStream streams as JSON frames. The caller must pass one or more `org_id`
+// query parameters; the subscription subject is scoped to those orgs (never
+// the unscoped `*All` wildcards), and any org the principal does not hold is
+// rejected with 403 rather than silently filtered - see api/sse/sse.go's
+// serve, which this mirrors. Clients can further narrow what they receive
+// with a `filter` query parameter and resume from a prior position with
+// `last_seq`.
+func (h *Handlers) Stream(w http.ResponseWriter, r *http.Request) {
+	if h.nats == nil || h.nats.JetStream() == nil {
+		sendError(w, http.StatusServiceUnavailable, "NATS_UNAVAILABLE", "streaming is not available")
+		return
+	}
+
+	orgIDs := r.URL.Query()["org_id"]
+	if len(orgIDs) == 0 {
+		sendError(w, http.StatusBadRequest, "MISSING_ORG_ID", "org_id is required")
+		return
+	}
+
+	principal, ok := middleware.PrincipalFromRequest(r)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "UNAUTHORIZED", "no authenticated principal")
+		return
+	}
+	for _, orgID := range orgIDs {
+		if !principal.HasOrg(orgID) {
+			sendError(w, http.StatusForbidden, "FORBIDDEN", "not a member of organization: "+orgID)
+			return
+		}
+	}
+
+	var deliverOpt nats.SubOpt = nats.DeliverNew()
+	if raw := r.URL.Query().Get("last_seq"); raw != "" {
+		seq, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "INVALID_LAST_SEQ", "last_seq must be an integer")
+			return
+		}
+		deliverOpt = nats.StartSequence(seq)
+	}
+
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("stream: websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	filter := parseStreamFilter(r.URL.Query().Get("filter"))
+
+	var sources []streamSource
+	for _, streamName := range []string{shared.StreamEntities, shared.StreamEvents, shared.StreamTelemetry} {
+		for _, orgID := range orgIDs {
+			sub, err := h.nats.EphemeralPullSubscribe(streamName, streamOrgSubject(streamName, orgID), deliverOpt)
+			if err != nil {
+				log.Printf("stream: failed to subscribe to %s for org %s: %v", streamName, orgID, err)
+				continue
+			}
+			defer sub.Drain()
+			sources = append(sources, streamSource{name: streamName, sub: sub})
+		}
+	}
+
+	frames := make(chan streamFrame, streamSendBuffer)
+	clientGone := make(chan struct{})
+
+	for _, src := range sources {
+		go pumpStream(src, filter, frames, clientGone)
+	}
+
+	go func() {
+		// Drain and discard anything the client sends; its only purpose here
+		// is letting us detect the connection closing.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				close(clientGone)
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-clientGone:
+			return
+		case frame := <-frames:
+			if err := conn.WriteJSON(frame); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteJSON(streamFrame{Type: "ping", Data: time.Now().UTC()}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamOrgSubject builds the JetStream subscription subject for streamName
+// scoped to orgID, e.g. "constellation.entities.acme.>". Callers must never
+// fall back to the unscoped *All wildcards - those span every org's traffic,
+// which the caller's principal has not necessarily been granted.
+func streamOrgSubject(streamName, orgID string) string {
+	switch streamName {
+	case shared.StreamEntities:
+		return "constellation.entities." + orgID + ".>"
+	case shared.StreamEvents:
+		return "constellation.events." + orgID + ".>"
+	case shared.StreamTelemetry:
+		return "constellation.telemetry." + orgID + ".>"
+	default:
+		return "constellation." + orgID + ".>"
+	}
+}
+
+// pumpStream fetches messages from src until clientGone is closed, filtering
+// and forwarding each message's CloudEvents data payload onto out. It applies
+// drop-oldest backpressure: when out is full it discards the oldest queued
+// frames and warns the client rather than blocking the fetch loop.
+func pumpStream(src streamSource, filter streamFilter, out chan streamFrame, clientGone <-chan struct{}) {
+	for {
+		select {
+		case <-clientGone:
+			return
+		default:
+		}
+
+		msgs, err := src.sub.Fetch(10, nats.MaxWait(2*time.Second))
+		if err != nil {
+			if err == nats.ErrTimeout {
+				continue
+			}
+			log.Printf("stream: fetch error on %s: %v", src.name, err)
+			return
+		}
+
+		for _, msg := range msgs {
+			var seq uint64
+			if meta, err := msg.Metadata(); err == nil {
+				seq = meta.Sequence.Stream
+			}
+
+			event, err := cloudevents.Decode(msg)
+			if err != nil {
+				continue
+			}
+			var payload map[string]interface{}
+			if err := event.DataAs(&payload); err != nil {
+				continue
+			}
+			if !filter.matches(payload) {
+				continue
+			}
+
+			sendFrame(out, streamFrame{
+				Type:    "message",
+				Stream:  src.name,
+				Subject: msg.Subject,
+				Seq:     seq,
+				Data:    payload,
+			})
+		}
+	}
+}
+
+func sendFrame(out chan streamFrame, frame streamFrame) {
+	select {
+	case out <- frame:
+		return
+	default:
+	}
+
+	// The client is falling behind: drop the two oldest queued frames to
+	// make room for this one and a warning rather than block the NATS fetch
+	// loop, so the client knows it missed something.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-out:
+		default:
+		}
+	}
+	select {
+	case out <- frame:
+	default:
+	}
+	select {
+	case out <- streamFrame{Type: "warning", Warning: "client is falling behind, frames dropped"}:
+	default:
+	}
+}
@@ -0,0 +1,187 @@
+// Command constellation-bench load-tests the JetStream pipeline end to end:
+// how many entities/telemetry/commands/events per second a deployment can
+// sustain from publish through worker ack, or (with --target=http) how the
+// HTTP API itself holds up. Modeled on nats-bench, but pipeline-aware: it
+// builds the same CloudEvents envelopes and subjects the real services use
+// (see pkg/shared and pkg/shared/cloudevents) instead of publishing raw
+// bytes to an arbitrary subject.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// benchConfig is the parsed, validated set of flags every run* function
+// reads from.
+type benchConfig struct {
+	target      string
+	natsURL     string
+	httpAddr    string
+	httpToken   string
+	publishers  int
+	subscribers int
+	msgSize     int
+	rate        float64
+	duration    time.Duration
+	orgIDs      []string
+}
+
+// report is what main emits, as both a table and JSON - the JSON shape is
+// what CI should diff between runs to gate regressions.
+type report struct {
+	Target           string       `json:"target"`
+	Publishers       int          `json:"publishers"`
+	Subscribers      int          `json:"subscribers"`
+	MsgSizeBytes     int          `json:"msg_size_bytes"`
+	RateLimit        float64      `json:"rate_limit_per_sec"`
+	Duration         string       `json:"duration"`
+	Published        int          `json:"published"`
+	PublishErrors    int          `json:"publish_errors"`
+	ThroughputPerSec float64      `json:"throughput_per_sec"`
+	PublishLatency   latencyStats `json:"publish_latency"`
+	DeliveryLatency  latencyStats `json:"delivery_latency,omitempty"`
+	StreamLag        uint64       `json:"stream_lag_messages,omitempty"`
+}
+
+func main() {
+	target := flag.String("target", "entities", "benchmark target: entities|telemetry|commands|events|http")
+	natsURL := flag.String("nats-url", "nats://localhost:4222", "NATS server URL (ignored for --target=http)")
+	httpAddr := flag.String("http-addr", "http://localhost:8080", "constellation-api base URL (only used for --target=http)")
+	httpToken := flag.String("http-token", "constellation-dev-token", "bearer token for --target=http")
+	publishers := flag.Int("publishers", 1, "number of concurrent publisher goroutines")
+	subscribers := flag.Int("subscribers", 1, "number of concurrent subscriber goroutines measuring delivery latency (ignored for --target=http)")
+	msgSize := flag.Int("msg-size", 256, "approximate payload padding size in bytes")
+	rate := flag.Float64("rate", 0, "combined publish rate in messages/sec across all publishers (0 = unlimited)")
+	duration := flag.Duration("duration", 10*time.Second, "how long to run")
+	orgIDsFlag := flag.String("org-ids", "", "comma-separated org ids to spread load across (default: a generated pool of 5)")
+	jsonOut := flag.String("json-out", "bench-results.json", "path to write the JSON report to (empty to skip)")
+	flag.Parse()
+
+	cfg := benchConfig{
+		target:      *target,
+		natsURL:     *natsURL,
+		httpAddr:    *httpAddr,
+		httpToken:   *httpToken,
+		publishers:  *publishers,
+		subscribers: *subscribers,
+		msgSize:     *msgSize,
+		rate:        *rate,
+		duration:    *duration,
+		orgIDs:      orgIDPool(*orgIDsFlag),
+	}
+
+	rep, err := run(cfg)
+	if err != nil {
+		log.Fatalf("bench run failed: %v", err)
+	}
+
+	printTable(rep)
+
+	if *jsonOut != "" {
+		if err := writeJSON(*jsonOut, rep); err != nil {
+			log.Printf("failed to write JSON report to %s: %v", *jsonOut, err)
+		} else {
+			fmt.Printf("\nJSON report written to %s\n", *jsonOut)
+		}
+	}
+}
+
+func orgIDPool(flagValue string) []string {
+	if flagValue != "" {
+		return strings.Split(flagValue, ",")
+	}
+
+	orgs := make([]string, 5)
+	for i := range orgs {
+		orgs[i] = uuid.New().String()
+	}
+	return orgs
+}
+
+func run(cfg benchConfig) (*report, error) {
+	if cfg.target == "http" {
+		result, err := runHTTPBench(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return buildHTTPReport(cfg, result), nil
+	}
+
+	result, err := runNATSBench(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return buildNATSReport(cfg, result), nil
+}
+
+func buildNATSReport(cfg benchConfig, result *natsRunResult) *report {
+	return &report{
+		Target:           cfg.target,
+		Publishers:       cfg.publishers,
+		Subscribers:      cfg.subscribers,
+		MsgSizeBytes:     cfg.msgSize,
+		RateLimit:        cfg.rate,
+		Duration:         cfg.duration.String(),
+		Published:        result.published,
+		PublishErrors:    result.publishErrors,
+		ThroughputPerSec: float64(result.published) / cfg.duration.Seconds(),
+		PublishLatency:   summarize(&result.publishLatency),
+		DeliveryLatency:  summarize(&result.deliveryLatency),
+		StreamLag:        result.streamLag,
+	}
+}
+
+func buildHTTPReport(cfg benchConfig, result *httpRunResult) *report {
+	return &report{
+		Target:           cfg.target,
+		Publishers:       cfg.publishers,
+		MsgSizeBytes:     cfg.msgSize,
+		RateLimit:        cfg.rate,
+		Duration:         cfg.duration.String(),
+		Published:        result.published,
+		PublishErrors:    result.publishErrors,
+		ThroughputPerSec: float64(result.published) / cfg.duration.Seconds(),
+		PublishLatency:   summarize(&result.publishLatency),
+	}
+}
+
+func printTable(r *report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "target:\t%s\n", r.Target)
+	fmt.Fprintf(w, "publishers:\t%d\n", r.Publishers)
+	fmt.Fprintf(w, "subscribers:\t%d\n", r.Subscribers)
+	fmt.Fprintf(w, "duration:\t%s\n", r.Duration)
+	fmt.Fprintf(w, "published:\t%d (errors: %d)\n", r.Published, r.PublishErrors)
+	fmt.Fprintf(w, "throughput:\t%.1f msg/sec\n", r.ThroughputPerSec)
+	fmt.Fprintf(w, "stream lag:\t%d messages\n", r.StreamLag)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "metric\tp50\tp95\tp99\tmin\tmax\tmean\tcount\n")
+	printLatencyRow(w, "publish", r.PublishLatency)
+	if r.DeliveryLatency.Count > 0 {
+		printLatencyRow(w, "delivery (ack)", r.DeliveryLatency)
+	}
+}
+
+func printLatencyRow(w *tabwriter.Writer, label string, s latencyStats) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+		label, s.P50, s.P95, s.P99, s.Min, s.Max, s.Mean, s.Count)
+}
+
+func writeJSON(path string, r *report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
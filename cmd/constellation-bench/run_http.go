@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"constellation-api/pkg/ontology"
+
+	"golang.org/x/time/rate"
+)
+
+// httpRunResult mirrors natsRunResult for the --target=http path. There's no
+// delivery/ack measurement here: EntityService.CreateEntity publishes its
+// entity-created event from a detached goroutine (see
+// api/services/entity.service.go), so the HTTP response doesn't wait for it
+// and this binary has no CloudEvents envelope to stamp a Bench-Ts header on.
+// publishLatency below is the full HTTP round trip instead.
+type httpRunResult struct {
+	published      int
+	publishErrors  int
+	publishLatency latencySample
+}
+
+// runHTTPBench drives the HTTP API directly: cfg.publishers goroutines each
+// POST realistic CreateEntityRequest payloads to /api/v1/entities at (up to)
+// cfg.rate requests/sec combined, until cfg.duration elapses.
+func runHTTPBench(cfg benchConfig) (*httpRunResult, error) {
+	result := &httpRunResult{}
+	var mu sync.Mutex
+
+	var limiter *rate.Limiter
+	if cfg.rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.rate), 1)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	deadline := time.Now().Add(cfg.duration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.publishers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID)))
+
+			for time.Now().Before(deadline) {
+				if limiter != nil {
+					if err := limiter.Wait(context.Background()); err != nil {
+						return
+					}
+				}
+
+				orgID := cfg.orgIDs[rng.Intn(len(cfg.orgIDs))]
+				latency, err := postEntity(client, cfg, orgID, rng)
+
+				mu.Lock()
+				if err != nil {
+					result.publishErrors++
+				} else {
+					result.published++
+					result.publishLatency.add(latency)
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+func postEntity(client *http.Client, cfg benchConfig, orgID string, rng *rand.Rand) (time.Duration, error) {
+	req := ontology.CreateEntityRequest{
+		EntityType: entityTypes[rng.Intn(len(entityTypes))],
+		Metadata: map[string]interface{}{
+			"bench":   true,
+			"padding": padding(cfg.msgSize),
+		},
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/api/v1/entities?org_id=%s", cfg.httpAddr, orgID)
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+cfg.httpToken)
+
+	start := time.Now()
+	resp, err := client.Do(httpReq)
+	elapsed := time.Since(start)
+	if err != nil {
+		return elapsed, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return elapsed, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return elapsed, nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"golang.org/x/time/rate"
+)
+
+// benchTsHeader carries the publish timestamp (RFC3339Nano) so a subscriber
+// can compute delivery latency by diffing against time.Now() on receipt.
+// It isn't a CloudEvents attribute - cloudevents.Encode doesn't touch it -
+// so it rides alongside the envelope the same way the DLQ headers in
+// pkg/services/workers/base.go ride alongside a republished message.
+const benchTsHeader = "Bench-Ts"
+
+// benchRunHeader tags every message this run publishes, so the subscriber
+// ignores unrelated traffic already flowing through a shared deployment.
+const benchRunHeader = "Bench-Run-Id"
+
+var entityTypes = []string{
+	shared.EntityTypeVehicle,
+	shared.EntityTypePerson,
+	shared.EntityTypeAsset,
+	shared.EntityTypeLocation,
+	shared.EntityTypeSensor,
+	shared.EntityTypeDevice,
+}
+
+// natsTarget describes where one of the entities/telemetry/commands/events
+// targets publishes and what stream a subscriber reads the same traffic back
+// from.
+type natsTarget struct {
+	stream    string
+	subjectOf func(orgID, entityID string) string
+	eventType string
+}
+
+func targetFor(name string) (natsTarget, error) {
+	switch name {
+	case "entities":
+		return natsTarget{
+			stream:    shared.StreamEntities,
+			subjectOf: func(orgID, _ string) string { return shared.EntityCreatedSubject(orgID) },
+			eventType: "com.constellation.entity." + shared.EventTypeCreated,
+		}, nil
+	case "telemetry":
+		return natsTarget{
+			stream:    shared.StreamTelemetry,
+			subjectOf: shared.TelemetryEntitySubject,
+			eventType: "com.constellation.telemetry.report",
+		}, nil
+	case "commands":
+		return natsTarget{
+			stream:    shared.StreamCommands,
+			subjectOf: shared.CommandEntitySubject,
+			eventType: "com.constellation.command.bench",
+		}, nil
+	case "events":
+		return natsTarget{
+			stream:    shared.StreamEvents,
+			subjectOf: func(orgID, _ string) string { return shared.SubjectEvents + "." + orgID + ".bench" },
+			eventType: "com.constellation.event.bench",
+		}, nil
+	default:
+		return natsTarget{}, fmt.Errorf("unknown target: %s", name)
+	}
+}
+
+// natsRunResult is what runNATSBench hands back to main for reporting.
+type natsRunResult struct {
+	published       int
+	publishErrors   int
+	publishLatency  latencySample
+	deliveryLatency latencySample
+	streamLag       uint64
+}
+
+// runNATSBench drives one of the direct-to-JetStream targets: cfg.publishers
+// goroutines publish CloudEvents-enveloped messages at (up to) cfg.rate
+// msgs/sec combined, while cfg.subscribers goroutines pull them back off the
+// stream via an ephemeral consumer to measure delivery latency, until
+// cfg.duration elapses.
+func runNATSBench(cfg benchConfig) (*natsRunResult, error) {
+	target, err := targetFor(cfg.target)
+	if err != nil {
+		return nil, err
+	}
+
+	nc, err := nats.Connect(cfg.natsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	runID := uuid.New().String()
+	result := &natsRunResult{}
+	var mu sync.Mutex
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+
+	var limiter *rate.Limiter
+	if cfg.rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.rate), 1)
+	}
+
+	var wg sync.WaitGroup
+
+	// A work-queue stream (commands/events) only allows a single filtered
+	// pull consumer per overlapping filter subject - each message is handed
+	// to exactly one consumer anyway, so more subscribers wouldn't measure
+	// anything a single one doesn't already.
+	numSubscribers := cfg.subscribers
+	if isWorkQueueStream(target.stream) && numSubscribers > 1 {
+		log.Printf("%s is a work-queue stream; capping --subscribers %d to 1", target.stream, numSubscribers)
+		numSubscribers = 1
+	}
+
+	// Subscribers first, so they're already pulling before publishers start.
+	subCtx, subCancel := context.WithCancel(context.Background())
+	for i := 0; i < numSubscribers; i++ {
+		sub, err := ephemeralPullSubscribe(js, target.stream, target.subjectOf("*", "*"))
+		if err != nil {
+			subCancel()
+			return nil, fmt.Errorf("failed to create subscriber %d: %w", i, err)
+		}
+
+		wg.Add(1)
+		go func(sub *nats.Subscription) {
+			defer wg.Done()
+			defer sub.Drain()
+			pullLoop(subCtx, sub, runID, &mu, &result.deliveryLatency)
+		}(sub)
+	}
+
+	var pubWg sync.WaitGroup
+	for i := 0; i < cfg.publishers; i++ {
+		pubWg.Add(1)
+		go func(workerID int) {
+			defer pubWg.Done()
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(workerID)))
+			publishLoop(ctx, js, target, cfg, runID, limiter, rng, &mu, result)
+		}(i)
+	}
+	pubWg.Wait()
+
+	// Give subscribers a little extra time to drain whatever publishers
+	// already handed JetStream, then stop them.
+	time.Sleep(500 * time.Millisecond)
+	subCancel()
+	wg.Wait()
+
+	if info, err := js.StreamInfo(target.stream); err == nil {
+		result.streamLag = info.State.Msgs
+	} else {
+		log.Printf("failed to read stream info for %s: %v", target.stream, err)
+	}
+
+	return result, nil
+}
+
+// publishLoop runs until ctx is done, publishing one message per tick (rate
+// limited if limiter is non-nil) and recording publish latency - the time
+// PublishMsg blocks waiting for the JetStream ack.
+func publishLoop(ctx context.Context, js nats.JetStreamContext, target natsTarget, cfg benchConfig, runID string, limiter *rate.Limiter, rng *rand.Rand, mu *sync.Mutex, result *natsRunResult) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+		}
+
+		orgID := cfg.orgIDs[rng.Intn(len(cfg.orgIDs))]
+		entityID := uuid.New().String()
+
+		msg, err := buildMessage(target, cfg, orgID, entityID, runID, rng)
+		if err != nil {
+			mu.Lock()
+			result.publishErrors++
+			mu.Unlock()
+			continue
+		}
+
+		start := time.Now()
+		_, err = js.PublishMsg(msg)
+		elapsed := time.Since(start)
+
+		mu.Lock()
+		if err != nil {
+			result.publishErrors++
+		} else {
+			result.published++
+			result.publishLatency.add(elapsed)
+		}
+		mu.Unlock()
+	}
+}
+
+// buildMessage constructs a CloudEvents-enveloped *nats.Msg for target,
+// carrying a realistic payload built from pkg/shared constants (random
+// entity type, the run's org pool) padded to cfg.msgSize bytes.
+func buildMessage(target natsTarget, cfg benchConfig, orgID, entityID, runID string, rng *rand.Rand) (*nats.Msg, error) {
+	entityType := entityTypes[rng.Intn(len(entityTypes))]
+
+	data := map[string]interface{}{
+		"entity_id":   entityID,
+		"org_id":      orgID,
+		"entity_type": entityType,
+		"status":      shared.StatusActive,
+		"padding":     padding(cfg.msgSize),
+	}
+
+	source := fmt.Sprintf("urn:constellation:bench:%s", runID)
+	event, err := cloudevents.New(uuid.New().String(), source, target.eventType, entityID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := target.subjectOf(orgID, entityID)
+	msg := nats.NewMsg(subject)
+	if err := cloudevents.Encode(msg, event, cloudevents.ModeStructured); err != nil {
+		return nil, err
+	}
+
+	msg.Header.Set(benchTsHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	msg.Header.Set(benchRunHeader, runID)
+	return msg, nil
+}
+
+// padding returns an n-byte filler string so published messages approximate
+// cfg.msgSize regardless of target; it's not meant to be realistic content,
+// just bulk.
+func padding(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 'x'
+	}
+	return string(b)
+}
+
+// isWorkQueueStream reports whether streamName uses WorkQueuePolicy
+// retention, where JetStream only allows a single filtered pull consumer per
+// overlapping filter subject.
+func isWorkQueueStream(streamName string) bool {
+	return streamName == shared.StreamCommands || streamName == shared.StreamEvents
+}
+
+// ephemeralPullSubscribe is similar to
+// embeddednats.EmbeddedNATS.EphemeralPullSubscribe, with two differences
+// driven by CONSTELLATION_COMMANDS/CONSTELLATION_EVENTS using
+// WorkQueuePolicy: it acks explicitly, since a workqueue stream requires an
+// explicit ack policy on pull consumers, and it can't start DeliverNew, since
+// a workqueue stream only allows DeliverAll - acceptable here since any
+// already-pending work-queue message is unacked leftover from a previous
+// run, and this consumer will ack it on the way past. This binary talks to
+// NATS directly rather than through EmbeddedNATS since it benchmarks a
+// separately running deployment, not one it owns.
+func ephemeralPullSubscribe(js nats.JetStreamContext, streamName, filterSubject string) (*nats.Subscription, error) {
+	opts := []nats.SubOpt{nats.BindStream(streamName), nats.AckExplicit()}
+	if !isWorkQueueStream(streamName) {
+		opts = append(opts, nats.DeliverNew())
+	}
+	return js.PullSubscribe(filterSubject, "", opts...)
+}
+
+// pullLoop fetches messages from sub until ctx is done, recording delivery
+// latency for every message tagged with runID.
+func pullLoop(ctx context.Context, sub *nats.Subscription, runID string, mu *sync.Mutex, into *latencySample) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		msgs, err := sub.Fetch(32, nats.MaxWait(500*time.Millisecond))
+		if err != nil {
+			continue
+		}
+
+		now := time.Now()
+		for _, msg := range msgs {
+			msg.Ack()
+
+			if msg.Header.Get(benchRunHeader) != runID {
+				continue
+			}
+
+			sentRaw := msg.Header.Get(benchTsHeader)
+			sent, err := time.Parse(time.RFC3339Nano, sentRaw)
+			if err != nil {
+				continue
+			}
+
+			mu.Lock()
+			into.add(now.Sub(sent))
+			mu.Unlock()
+		}
+	}
+}
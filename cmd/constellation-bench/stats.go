@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// latencySample collects durations from many goroutines; samples is only
+// read after every publisher/subscriber goroutine has stopped, so no lock is
+// needed once collection ends.
+type latencySample struct {
+	samples []time.Duration
+}
+
+func (s *latencySample) add(d time.Duration) {
+	s.samples = append(s.samples, d)
+}
+
+// latencyStats is the percentile/summary view of a latencySample, reported
+// both in the human table and the JSON output.
+type latencyStats struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P95   time.Duration `json:"p95_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// summarize sorts samples (destructively) and computes latencyStats. An
+// empty sample set reports a zero-value latencyStats rather than panicking,
+// since a subscriber count of zero or a target with no delivery measurement
+// (e.g. http) is a valid, expected case.
+func summarize(s *latencySample) latencyStats {
+	n := len(s.samples)
+	if n == 0 {
+		return latencyStats{}
+	}
+
+	sort.Slice(s.samples, func(i, j int) bool { return s.samples[i] < s.samples[j] })
+
+	var sum time.Duration
+	for _, d := range s.samples {
+		sum += d
+	}
+
+	return latencyStats{
+		Count: n,
+		Min:   s.samples[0],
+		Mean:  sum / time.Duration(n),
+		P50:   percentile(s.samples, 50),
+		P95:   percentile(s.samples, 95),
+		P99:   percentile(s.samples, 99),
+		Max:   s.samples[n-1],
+	}
+}
+
+// percentile returns the p-th percentile of sorted (ascending) durations,
+// clamping the computed rank into range so p=100 returns the max.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p * len(sorted)) / 100
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
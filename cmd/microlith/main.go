@@ -12,6 +12,7 @@ import (
 
 	"constellation-api/api"
 	"constellation-api/api/middleware"
+	"constellation-api/api/services"
 	"constellation-api/db"
 	"constellation-api/pkg/shared"
 	embeddednats "constellation-api/pkg/services/embedded-nats"
@@ -38,16 +39,17 @@ func initDB() error {
 		return fmt.Errorf("failed to initialize database service: %w", err)
 	}
 
-	// Verify schema is properly initialized
+	// db.New already migrated the schema to the latest version; confirm the
+	// tables callers expect are actually there.
 	if err := dbService.VerifySchema(); err != nil {
-		log.Printf("Schema verification failed: %v", err)
-		log.Println("Attempting to initialize schema...")
-		if err := dbService.InitializeSchema(); err != nil {
-			return fmt.Errorf("failed to initialize schema: %w", err)
-		}
+		return fmt.Errorf("schema verification failed: %w", err)
 	}
 
-	log.Println("Database service initialized successfully")
+	version, err := dbService.GetSchemaVersion(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	log.Printf("Database service initialized successfully (schema version %d)", version)
 	return nil
 }
 
@@ -72,6 +74,11 @@ func initNATS() error {
 		return fmt.Errorf("failed to create constellation streams: %w", err)
 	}
 
+	// Create the entity state/presence KV buckets
+	if err := nats.CreateStateCacheBuckets(); err != nil {
+		return fmt.Errorf("failed to create state cache buckets: %w", err)
+	}
+
 	// Create durable consumers
 	consumers := []struct {
 		stream   string
@@ -84,8 +91,9 @@ func initNATS() error {
 		{shared.StreamTelemetry, shared.ConsumerTelemetryProcessor, shared.SubjectTelemetryAll},
 	}
 
+	workerConfig := workers.DefaultWorkerConfig()
 	for _, c := range consumers {
-		if err := nats.CreateDurableConsumer(c.stream, c.consumer, c.filter); err != nil {
+		if err := nats.CreateDurableConsumer(c.stream, c.consumer, c.filter, workerConfig.MaxDeliver, workerConfig.AckWait); err != nil {
 			return fmt.Errorf("failed to create consumer %s: %w", c.consumer, err)
 		}
 	}
@@ -116,8 +124,12 @@ func main() {
 		log.Fatal("Failed to initialize NATS:", err)
 	}
 
+	// Shared between the HTTP API and the NATS command worker, so hooks
+	// registered on it fire regardless of which write path triggered it.
+	entityService := services.NewEntityService(dbService.GetDB(), nats)
+
 	// Start NATS workers
-	workerManager, err := workers.NewManager(nats)
+	workerManager, err := workers.NewManager(nats, dbService, entityService)
 	if err != nil {
 		log.Fatal("Failed to create worker manager:", err)
 	}
@@ -133,7 +145,10 @@ func main() {
 	mux := http.NewServeMux()
 
 	// Initialize handlers
-	handlers := api.NewHandlers(dbService.GetDB(), nats)
+	handlers, err := api.NewHandlers(dbService.GetDB(), nats, entityService)
+	if err != nil {
+		log.Fatal("Failed to create API handlers:", err)
+	}
 	handlers.RegisterRoutes(mux, nats)
 
 	// Apply CORS middleware to all routes
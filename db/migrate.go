@@ -0,0 +1,255 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed schema/migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationFilePattern matches embedded migration filenames like
+// "0001_init.up.sql" or "0002_add_entity_resource_version.down.sql".
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+// migration is one versioned schema change: its forward (UpSQL) and
+// backward (DownSQL) DDL, plus the SHA-256 checksum of UpSQL that
+// schema_migrations records so a later edit to an already-applied file is
+// detected instead of silently ignored.
+type migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+// loadMigrations reads every embedded migration file, pairs up.sql/down.sql
+// by version, and returns them sorted ascending by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationsFS.ReadDir("schema/migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := migrationsFS.ReadFile("schema/migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		switch match[3] {
+		case "up":
+			m.UpSQL = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		case "down":
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s is missing its up.sql file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// createMigrationsTableSQL is infrastructure DDL run directly (not through
+// the migration file set) since schema_migrations must exist before pending
+// migrations can even be computed.
+const createMigrationsTableSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    checksum   TEXT NOT NULL,
+    applied_at TEXT NOT NULL
+)`
+
+// appliedMigration is the bookkeeping schema_migrations stores for a version
+// that has already run.
+type appliedMigration struct {
+	Version  int
+	Checksum string
+}
+
+func (s *Service) appliedMigrations(ctx context.Context) (map[int]appliedMigration, error) {
+	if _, err := s.DB.ExecContext(ctx, createMigrationsTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `SELECT version, checksum FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.Version, &a.Checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied[a.Version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// MigrateSchema brings the database up to target, or the latest embedded
+// migration if target is 0. Pending migrations run in version order, each in
+// its own transaction, and are recorded in schema_migrations together with
+// the SHA-256 checksum of their up.sql. MigrateSchema refuses to proceed if a
+// previously-applied migration's recorded checksum no longer matches its
+// embedded file, since that means the migration history was edited after
+// being applied somewhere.
+func (s *Service) MigrateSchema(ctx context.Context, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if target <= 0 {
+		target = migrations[len(migrations)-1].Version
+	}
+
+	for _, m := range migrations {
+		if existing, ok := applied[m.Version]; ok {
+			if existing.Checksum != m.Checksum {
+				return fmt.Errorf("migration %d_%s checksum mismatch: applied version does not match embedded file", m.Version, m.Name)
+			}
+			continue
+		}
+		if m.Version > target {
+			break
+		}
+
+		if err := s.execInTx(ctx, m.UpSQL); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if _, err := s.DB.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+			m.Version, m.Name, m.Checksum, time.Now().UTC().Format(time.RFC3339),
+		); err != nil {
+			return fmt.Errorf("failed to record migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+// Rollback reverts applied migrations down to (but not including) target, by
+// running each one's down.sql in descending version order and removing its
+// schema_migrations row.
+func (s *Service) Rollback(ctx context.Context, target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version > migrations[j].Version })
+
+	for _, m := range migrations {
+		if m.Version <= target {
+			continue
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.DownSQL == "" {
+			return fmt.Errorf("migration %d_%s has no down.sql", m.Version, m.Name)
+		}
+
+		if err := s.execInTx(ctx, m.DownSQL); err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		if _, err := s.DB.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		log.Printf("Rolled back migration %04d_%s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+// execInTx runs sqlText inside its own transaction, rolling back on error.
+func (s *Service) execInTx(ctx context.Context, sqlText string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, sqlText); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSchemaVersion returns the highest migration version currently applied,
+// or 0 if none have run yet.
+func (s *Service) GetSchemaVersion(ctx context.Context) (int, error) {
+	if _, err := s.DB.ExecContext(ctx, createMigrationsTableSQL); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var version sql.NullInt64
+	if err := s.DB.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	if !version.Valid {
+		return 0, nil
+	}
+
+	return int(version.Int64), nil
+}
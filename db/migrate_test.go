@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.DBPath = filepath.Join(t.TempDir(), "constellation.db")
+
+	svc, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	return svc
+}
+
+func TestMigrateSchemaAppliesAllMigrations(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	// New already ran MigrateSchema via AutoInitialize; confirm it landed on
+	// the latest embedded version and VerifySchema is happy with the result.
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	want := migrations[len(migrations)-1].Version
+
+	got, err := svc.GetSchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetSchemaVersion failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("schema version = %d, want %d", got, want)
+	}
+
+	if err := svc.VerifySchema(); err != nil {
+		t.Errorf("VerifySchema failed: %v", err)
+	}
+}
+
+func TestMigrateSchemaIsIdempotent(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	if err := svc.MigrateSchema(ctx, 0); err != nil {
+		t.Fatalf("second MigrateSchema call failed: %v", err)
+	}
+
+	version, err := svc.GetSchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetSchemaVersion failed: %v", err)
+	}
+	migrations, _ := loadMigrations()
+	if want := migrations[len(migrations)-1].Version; version != want {
+		t.Errorf("schema version after re-running = %d, want %d", version, want)
+	}
+}
+
+func TestMigrateSchemaRejectsChecksumMismatch(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.DB.ExecContext(ctx,
+		`UPDATE schema_migrations SET checksum = 'tampered' WHERE version = 1`,
+	); err != nil {
+		t.Fatalf("failed to tamper with schema_migrations: %v", err)
+	}
+
+	if err := svc.MigrateSchema(ctx, 0); err == nil {
+		t.Fatal("expected MigrateSchema to reject a tampered checksum, got nil error")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	if err := svc.Rollback(ctx, 0); err != nil {
+		t.Fatalf("Rollback to 0 failed: %v", err)
+	}
+
+	version, err := svc.GetSchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetSchemaVersion failed: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("schema version after full rollback = %d, want 0", version)
+	}
+
+	var exists int
+	if err := svc.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='entities'`,
+	).Scan(&exists); err != nil {
+		t.Fatalf("failed to check entities table: %v", err)
+	}
+	if exists != 0 {
+		t.Error("entities table still exists after rolling back migration 0001_init")
+	}
+}
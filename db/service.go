@@ -1,8 +1,8 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 	"log"
 	"os"
@@ -11,9 +11,6 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schemaFS embed.FS
-
 // Service represents the database service with connection management
 type Service struct {
 	DB     *sql.DB
@@ -48,9 +45,6 @@ func New(config *Config) (*Service, error) {
 		DBPath: config.DBPath,
 	}
 
-	// Check if database file exists
-	dbExists := fileExists(config.DBPath)
-
 	// Ensure the directory exists
 	dbDir := filepath.Dir(config.DBPath)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
@@ -75,35 +69,20 @@ func New(config *Config) (*Service, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Initialize schema if database is new and auto-initialization is enabled
-	if !dbExists && config.AutoInitialize {
-		log.Println("Database not found, initializing schema...")
-		if err := service.InitializeSchema(); err != nil {
-			return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	// Bring the schema up to date. Migrations are individually idempotent
+	// (schema_migrations tracks what's already applied), so this is safe to
+	// run against both a brand new database and one left over from before
+	// the migration system existed.
+	if config.AutoInitialize {
+		if err := service.MigrateSchema(context.Background(), 0); err != nil {
+			return nil, fmt.Errorf("failed to migrate schema: %w", err)
 		}
-		log.Println("Database schema initialized successfully")
 	}
 
 	log.Printf("Database service initialized: %s", config.DBPath)
 	return service, nil
 }
 
-// InitializeSchema loads and executes the schema.sql file
-func (s *Service) InitializeSchema() error {
-	// Read schema from embedded filesystem
-	schemaSQL, err := schemaFS.ReadFile("schema.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
-	}
-
-	// Execute schema
-	if _, err := s.DB.Exec(string(schemaSQL)); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
-	}
-
-	return nil
-}
-
 // VerifySchema checks if the database schema is properly initialized
 func (s *Service) VerifySchema() error {
 	// Check if core tables exist
@@ -188,23 +167,3 @@ func (s *Service) GetStats() sql.DBStats {
 	return s.DB.Stats()
 }
 
-// fileExists checks if a file exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return !os.IsNotExist(err)
-}
-
-// MigrateSchema applies any pending schema migrations
-// This is a placeholder for future migration support
-func (s *Service) MigrateSchema() error {
-	// TODO: Implement migration system
-	log.Println("Schema migration not yet implemented")
-	return nil
-}
-
-// GetSchemaVersion returns the current schema version
-// This is a placeholder for future versioning support
-func (s *Service) GetSchemaVersion() (string, error) {
-	// TODO: Implement schema versioning
-	return "1.0.0", nil
-}
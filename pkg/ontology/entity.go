@@ -11,6 +11,7 @@ type Entity struct {
 	Status     string    `json:"status" db:"status"`
 	Priority   string    `json:"priority" db:"priority"`
 	IsLive     bool      `json:"is_live" db:"is_live"`
+	ResourceVersion int64 `json:"resource_version" db:"resource_version"`
 	Latitude   *float64  `json:"latitude,omitempty" db:"latitude"`
 	Longitude  *float64  `json:"longitude,omitempty" db:"longitude"`
 	Altitude   *float64  `json:"altitude,omitempty" db:"altitude"`
@@ -0,0 +1,149 @@
+// Package blobstore holds large per-entity objects - imagery, LIDAR frames,
+// mission recordings - that don't belong on a JetStream message stream, in a
+// JetStream Object Store bucket per org (see EmbeddedNATS.ObjectStoreForOrg
+// for bucket provisioning and lifecycle limits).
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+	"constellation-api/pkg/shared/httperr"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// publishTimeout bounds the blob_added CloudEvent publish Put issues after a
+// successful upload, the same way EntityService bounds its own event
+// publishes: the upload itself has already completed, so this shouldn't be
+// allowed to hang the response on a slow NATS round trip.
+const publishTimeout = 5 * time.Second
+
+// Info is the subset of nats.ObjectInfo callers need, kept separate so
+// api/handlers.go doesn't have to import nats.go just to read an upload
+// result.
+type Info struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Size        uint64    `json:"size"`
+	Digest      string    `json:"digest"`
+	ModTime     time.Time `json:"mod_time"`
+	URI         string    `json:"uri"`
+}
+
+// Service puts, gets, and deletes per-org, per-entity blobs.
+type Service struct {
+	nats *embeddednats.EmbeddedNATS
+}
+
+// NewService builds the Service. Buckets are created lazily, per org, on
+// first use - see EmbeddedNATS.ObjectStoreForOrg.
+func NewService(natsClient *embeddednats.EmbeddedNATS) *Service {
+	return &Service{nats: natsClient}
+}
+
+// Put streams reader's contents into orgID's blob bucket as name, publishes a
+// blob_added CloudEvent for entityID on success, and returns the stored
+// object's info.
+func (s *Service) Put(ctx context.Context, orgID, entityID, name, description string, headers nats.Header, reader io.Reader) (*Info, error) {
+	if name == "" {
+		return nil, httperr.ErrValidation("blob name is required")
+	}
+
+	store, err := s.nats.ObjectStoreForOrg(orgID)
+	if err != nil {
+		return nil, httperr.ErrInternal(fmt.Errorf("failed to open blob bucket: %w", err))
+	}
+
+	objInfo, err := store.Put(&nats.ObjectMeta{Name: name, Description: description, Headers: headers}, reader)
+	if err != nil {
+		return nil, httperr.ErrInternal(fmt.Errorf("failed to store blob: %w", err))
+	}
+
+	info := toInfo(objInfo)
+	s.publishBlobAdded(orgID, entityID, info)
+	return info, nil
+}
+
+// Get opens name for streaming download from orgID's blob bucket. Callers
+// must close the result.
+func (s *Service) Get(orgID, name string) (nats.ObjectResult, error) {
+	store, err := s.nats.ObjectStoreForOrg(orgID)
+	if err != nil {
+		return nil, httperr.ErrInternal(fmt.Errorf("failed to open blob bucket: %w", err))
+	}
+
+	result, err := store.Get(name)
+	if err != nil {
+		if errors.Is(err, nats.ErrObjectNotFound) {
+			return nil, httperr.ErrNotFound("blob not found: " + name)
+		}
+		return nil, httperr.ErrInternal(fmt.Errorf("failed to open blob: %w", err))
+	}
+	return result, nil
+}
+
+// Delete removes name from orgID's blob bucket.
+func (s *Service) Delete(orgID, name string) error {
+	store, err := s.nats.ObjectStoreForOrg(orgID)
+	if err != nil {
+		return httperr.ErrInternal(fmt.Errorf("failed to open blob bucket: %w", err))
+	}
+
+	if err := store.Delete(name); err != nil {
+		if errors.Is(err, nats.ErrObjectNotFound) {
+			return httperr.ErrNotFound("blob not found: " + name)
+		}
+		return httperr.ErrInternal(fmt.Errorf("failed to delete blob: %w", err))
+	}
+	return nil
+}
+
+func toInfo(oi *nats.ObjectInfo) *Info {
+	return &Info{
+		Name:        oi.Name,
+		Description: oi.Description,
+		Size:        oi.Size,
+		Digest:      oi.Digest,
+		ModTime:     oi.ModTime,
+		URI:         fmt.Sprintf("nats://obj/%s/%s", oi.Bucket, oi.Name),
+	}
+}
+
+// publishBlobAdded publishes a com.constellation.entity.blob_added
+// CloudEvent carrying the object's digest, size, and NATS URI, so a
+// subscriber can decide whether to fetch it without pulling the whole blob
+// through the event stream.
+func (s *Service) publishBlobAdded(orgID, entityID string, info *Info) {
+	if s.nats == nil || s.nats.JetStream() == nil {
+		return
+	}
+
+	data := map[string]interface{}{
+		"name":   info.Name,
+		"size":   info.Size,
+		"digest": info.Digest,
+		"uri":    info.URI,
+	}
+
+	source := fmt.Sprintf("urn:constellation:org:%s:entity:%s", orgID, entityID)
+	event, err := cloudevents.New(uuid.New().String(), source, "com.constellation.entity."+shared.EventTypeBlobAdded, entityID, data)
+	if err != nil {
+		return
+	}
+
+	subject := shared.EntityBlobAddedSubject(orgID, entityID)
+	msgID := fmt.Sprintf("%s-%s-%d", entityID, info.Name, info.ModTime.UnixNano())
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	s.nats.PublishEventWithDedup(ctx, subject, event, cloudevents.ModeStructured, msgID)
+}
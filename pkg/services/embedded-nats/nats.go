@@ -6,6 +6,8 @@ import (
 	"log"
 	"time"
 
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 )
@@ -20,6 +22,14 @@ type Config struct {
 	EnableTLS      bool
 	TLSCert        string
 	TLSKey         string
+
+	// BlobMaxBytesPerBucket bounds each org's blob Object Store bucket (see
+	// ObjectStoreForOrg), so a single tenant's imagery/LIDAR uploads can't
+	// exhaust disk for everyone else. Zero means unbounded.
+	BlobMaxBytesPerBucket int64
+	// BlobTTL expires blobs this long after they were stored if non-zero,
+	// whether or not they have been read since; zero keeps them indefinitely.
+	BlobTTL time.Duration
 }
 
 type EmbeddedNATS struct {
@@ -54,6 +64,8 @@ func DefaultConfig() *Config {
 		MaxFileStore:    2 * 1024 * 1024 * 1024, // 2GB
 		JetStreamDomain: "constellation",
 		EnableTLS:       false,
+		BlobMaxBytesPerBucket: 1024 * 1024 * 1024, // 1GB per org
+		BlobTTL:               0,                  // keep indefinitely
 	}
 }
 
@@ -75,11 +87,12 @@ func (en *EmbeddedNATS) Start() error {
 		StoreDir:   en.config.DataDir,
 	}
 	
-	// Only enable websocket if we have TLS
-	if en.config.EnableTLS {
+	// Websocket is always enabled when a port is configured; TLS is only
+	// required in production deployments, not for local development.
+	if en.config.WSPort != 0 {
 		opts.Websocket = server.WebsocketOpts{
-			Port: en.config.WSPort,
-			NoTLS: false,
+			Port:  en.config.WSPort,
+			NoTLS: !en.config.EnableTLS,
 		}
 	}
 
@@ -254,6 +267,24 @@ func (en *EmbeddedNATS) CreateConstellationStreams() error {
 			AllowDirect:     false, // Commands must go through stream
 			DiscardPolicy:   nats.DiscardNew, // Reject new commands if full
 		},
+		{
+			// Dead-letter stream: BaseWorker republishes here, with the
+			// original subject/delivery count/error preserved as headers,
+			// any message a worker Term's after exhausting MaxDeliver or
+			// hitting a permanent error.
+			Name:            "CONSTELLATION_DLQ",
+			Subjects:        []string{"constellation.dlq.>"},
+			Retention:       nats.LimitsPolicy,
+			MaxMsgs:         50000,
+			MaxBytes:        128 * 1024 * 1024, // 128MB
+			MaxAge:          30 * 24 * time.Hour, // 30 days, long enough to investigate and replay
+			MaxMsgSize:      1024 * 1024,         // 1MB
+			Replicas:        1,
+			DuplicateWindow: 2 * time.Minute,
+			AllowRollup:     false,
+			AllowDirect:     true,
+			DiscardPolicy:   nats.DiscardOld,
+		},
 	}
 
 	for _, stream := range streams {
@@ -265,29 +296,116 @@ func (en *EmbeddedNATS) CreateConstellationStreams() error {
 	return nil
 }
 
-func (en *EmbeddedNATS) PublishWithDedup(subject string, data []byte, msgID string) error {
+// CreateStateCacheBuckets creates the JetStream KV buckets
+// pkg/services/statecache binds to: the latest entity state (kept
+// indefinitely, with history so recent changes can be inspected) and
+// presence (TTL'd so an entity that stops sending telemetry falls off the
+// roster on its own rather than needing an explicit offline event).
+func (en *EmbeddedNATS) CreateStateCacheBuckets() error {
+	if en.js == nil {
+		return fmt.Errorf("JetStream not initialized")
+	}
+
+	if _, err := en.js.CreateKeyValue(&nats.KeyValueConfig{
+		Bucket:  shared.KVBucketEntities,
+		History: 8,
+	}); err != nil {
+		return fmt.Errorf("failed to create entity state bucket: %w", err)
+	}
+
+	if _, err := en.js.CreateKeyValue(&nats.KeyValueConfig{
+		Bucket: shared.KVBucketPresence,
+		TTL:    60 * time.Second,
+	}); err != nil {
+		return fmt.Errorf("failed to create presence bucket: %w", err)
+	}
+
+	log.Printf("Created state cache KV buckets: %s, %s", shared.KVBucketEntities, shared.KVBucketPresence)
+	return nil
+}
+
+// blobBucketTmpl names an org's blob Object Store bucket: constellation-blobs-<org_id>.
+const blobBucketTmpl = "constellation-blobs-%s"
+
+// ObjectStoreForOrg returns orgID's blob Object Store bucket, creating it
+// with the Config.BlobMaxBytesPerBucket/BlobTTL limits on first use. Buckets
+// are per-org rather than precreated like the stream/KV buckets above, since
+// the set of orgs isn't known at startup.
+func (en *EmbeddedNATS) ObjectStoreForOrg(orgID string) (nats.ObjectStore, error) {
+	if en.js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	bucket := fmt.Sprintf(blobBucketTmpl, orgID)
+
+	if store, err := en.js.ObjectStore(bucket); err == nil {
+		return store, nil
+	}
+
+	store, err := en.js.CreateObjectStore(&nats.ObjectStoreConfig{
+		Bucket:   bucket,
+		MaxBytes: en.config.BlobMaxBytesPerBucket,
+		TTL:      en.config.BlobTTL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob bucket %s: %w", bucket, err)
+	}
+
+	log.Printf("Created blob bucket: %s", bucket)
+	return store, nil
+}
+
+// PublishWithDedup publishes data to subject tagged with the JetStream
+// de-duplication header msgID, honouring ctx's deadline/cancellation instead
+// of blocking on the ack indefinitely.
+func (en *EmbeddedNATS) PublishWithDedup(ctx context.Context, subject string, data []byte, msgID string) error {
 	msg := nats.NewMsg(subject)
 	msg.Data = data
 	msg.Header.Set("Nats-Msg-Id", msgID)
-	
-	_, err := en.js.PublishMsg(msg)
+
+	_, err := en.js.PublishMsg(msg, nats.Context(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
-	
+
+	return nil
+}
+
+// PublishEventWithDedup encodes e onto subject as a CloudEvents envelope
+// (see pkg/shared/cloudevents) in the given mode and publishes it tagged
+// with the JetStream de-duplication header msgID, honouring ctx's
+// deadline/cancellation the same way PublishWithDedup does.
+func (en *EmbeddedNATS) PublishEventWithDedup(ctx context.Context, subject string, e *cloudevents.Event, mode cloudevents.Mode, msgID string) error {
+	msg := nats.NewMsg(subject)
+	if err := cloudevents.Encode(msg, e, mode); err != nil {
+		return fmt.Errorf("failed to encode cloudevents envelope: %w", err)
+	}
+	msg.Header.Set("Nats-Msg-Id", msgID)
+
+	_, err := en.js.PublishMsg(msg, nats.Context(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
 	return nil
 }
 
-func (en *EmbeddedNATS) CreateDurableConsumer(streamName, consumerName string, filterSubject string) error {
+// CreateDurableConsumer creates the given durable consumer if it doesn't
+// already exist. maxDeliver and ackWait must match the values the worker
+// that binds to this consumer passes to PullSubscribe (see
+// workers.WorkerConfig) - JetStream rejects a bind whose requested config
+// disagrees with the consumer's actual config, so there must be exactly one
+// source of truth for these two values.
+func (en *EmbeddedNATS) CreateDurableConsumer(streamName, consumerName, filterSubject string, maxDeliver int, ackWait time.Duration) error {
 	config := &nats.ConsumerConfig{
-		Durable:         consumerName,
-		FilterSubject:   filterSubject,
-		AckPolicy:       nats.AckExplicitPolicy,
-		AckWait:         30 * time.Second,
-		MaxDeliver:      3,
-		MaxAckPending:   1000,
-		DeliverPolicy:   nats.DeliverAllPolicy,
-		ReplayPolicy:    nats.ReplayInstantPolicy,
+		Durable:       consumerName,
+		FilterSubject: filterSubject,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       ackWait,
+		MaxDeliver:    maxDeliver,
+		MaxAckPending: 1000,
+		DeliverPolicy: nats.DeliverAllPolicy,
+		ReplayPolicy:  nats.ReplayInstantPolicy,
 	}
 
 	// Try to get existing consumer
@@ -308,6 +426,109 @@ func (en *EmbeddedNATS) CreateDurableConsumer(streamName, consumerName string, f
 	return nil
 }
 
+// EphemeralPullSubscribe creates a non-durable pull subscription bound to
+// streamName, filtered to filterSubject, starting delivery according to
+// deliverOpt (e.g. nats.DeliverNew() or nats.StartSequence(seq)).
+// The caller owns the returned subscription's lifecycle and should Drain it
+// when done; JetStream cleans up the underlying ephemeral consumer once it
+// goes idle.
+func (en *EmbeddedNATS) EphemeralPullSubscribe(streamName, filterSubject string, deliverOpt nats.SubOpt) (*nats.Subscription, error) {
+	if en.js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	opts := []nats.SubOpt{
+		nats.BindStream(streamName),
+		nats.AckNone(),
+	}
+	if deliverOpt != nil {
+		opts = append(opts, deliverOpt)
+	}
+
+	sub, err := en.js.PullSubscribe(filterSubject, "", opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ephemeral consumer on stream %s: %w", streamName, err)
+	}
+
+	return sub, nil
+}
+
+// StreamInfo returns the current StreamInfo for name, including its config,
+// message/byte counts, first/last sequence, and per-subject message counts
+// (State.Subjects).
+func (en *EmbeddedNATS) StreamInfo(name string) (*nats.StreamInfo, error) {
+	if en.js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	return en.js.StreamInfo(name, &nats.StreamInfoRequest{SubjectsFilter: ">"})
+}
+
+// ListStreamInfo returns StreamInfo for every stream currently known to
+// JetStream, for the admin/debug streams listing.
+func (en *EmbeddedNATS) ListStreamInfo() ([]*nats.StreamInfo, error) {
+	if en.js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	var infos []*nats.StreamInfo
+	for info := range en.js.StreamsInfo() {
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// ListConsumerInfo returns ConsumerInfo for every consumer bound to
+// streamName, including each consumer's pending, ack-pending, redelivered,
+// and last-delivered sequence.
+func (en *EmbeddedNATS) ListConsumerInfo(streamName string) ([]*nats.ConsumerInfo, error) {
+	if en.js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	var infos []*nats.ConsumerInfo
+	for info := range en.js.ConsumersInfo(streamName) {
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// PurgeStream removes all messages from the named stream, for operator
+// recovery from the admin/debug surface.
+func (en *EmbeddedNATS) PurgeStream(name string) error {
+	if en.js == nil {
+		return fmt.Errorf("JetStream not initialized")
+	}
+
+	return en.js.PurgeStream(name)
+}
+
+// ResetConsumer deletes and recreates consumerName on streamName using its
+// existing configuration, clearing accumulated pending/redelivery state
+// without losing the consumer's filter/ack settings.
+func (en *EmbeddedNATS) ResetConsumer(streamName, consumerName string) error {
+	if en.js == nil {
+		return fmt.Errorf("JetStream not initialized")
+	}
+
+	info, err := en.js.ConsumerInfo(streamName, consumerName)
+	if err != nil {
+		return fmt.Errorf("failed to look up consumer %s: %w", consumerName, err)
+	}
+
+	if err := en.js.DeleteConsumer(streamName, consumerName); err != nil {
+		return fmt.Errorf("failed to delete consumer %s: %w", consumerName, err)
+	}
+
+	if _, err := en.js.AddConsumer(streamName, &info.Config); err != nil {
+		return fmt.Errorf("failed to recreate consumer %s: %w", consumerName, err)
+	}
+
+	return nil
+}
+
 func (en *EmbeddedNATS) Connection() *nats.Conn {
 	return en.nc
 }
@@ -0,0 +1,30 @@
+package retention
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Policy is a per-organization, per-table row-expiry rule: rows older than
+// DurationSeconds are deleted, and if MaxRows is set the table is further
+// trimmed to its most recent MaxRows rows (by ROWID) for that organization.
+type Policy struct {
+	OrgID           string    `json:"org_id" db:"org_id"`
+	TableName       string    `json:"table_name" db:"table_name"`
+	DurationSeconds int64     `json:"duration_seconds" db:"duration_seconds"`
+	MaxRows         *int64    `json:"max_rows,omitempty" db:"max_rows"`
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MarshalBinary and UnmarshalBinary let a Policy be shipped as the payload
+// of a NATS message (e.g. a KV put) without callers having to know it's JSON
+// underneath.
+func (p *Policy) MarshalBinary() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *Policy) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, p)
+}
@@ -0,0 +1,147 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"constellation-api/pkg/shared/httperr"
+)
+
+// timeColumns maps the tables a retention policy may target to the column
+// that holds their age; it's also the whitelist RetentionWorker uses to
+// build its DELETE statements, since table_name must never be interpolated
+// unchecked into SQL.
+var timeColumns = map[string]string{
+	"entities":   "created_at",
+	"telemetry":  "recorded_at",
+	"audit_log":  "created_at",
+}
+
+// TimeColumn returns the age column for table, and whether table is a valid
+// retention target at all.
+func TimeColumn(table string) (string, bool) {
+	col, ok := timeColumns[table]
+	return col, ok
+}
+
+// Service manages retention_policies rows.
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// UpsertPolicy creates or replaces the policy for (org_id, table_name).
+func (s *Service) UpsertPolicy(ctx context.Context, policy *Policy) error {
+	if _, ok := TimeColumn(policy.TableName); !ok {
+		return httperr.ErrValidation(fmt.Sprintf("unsupported retention table: %s", policy.TableName))
+	}
+	if policy.DurationSeconds <= 0 {
+		return httperr.ErrValidation("duration_seconds must be positive")
+	}
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO retention_policies (org_id, table_name, duration_seconds, max_rows, enabled, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(org_id, table_name) DO UPDATE SET
+		   duration_seconds = excluded.duration_seconds,
+		   max_rows = excluded.max_rows,
+		   enabled = excluded.enabled,
+		   updated_at = excluded.updated_at`,
+		policy.OrgID, policy.TableName, policy.DurationSeconds, policy.MaxRows, policy.Enabled,
+		now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert retention policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetPolicy returns the policy for (orgID, tableName).
+func (s *Service) GetPolicy(ctx context.Context, orgID, tableName string) (*Policy, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT org_id, table_name, duration_seconds, max_rows, enabled, created_at, updated_at
+		 FROM retention_policies WHERE org_id = ? AND table_name = ?`,
+		orgID, tableName,
+	)
+
+	policy, err := scanPolicy(row)
+	if err == sql.ErrNoRows {
+		return nil, httperr.ErrNotFound("retention policy not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// ListPolicies returns every retention policy across all organizations, the
+// set RetentionWorker sweeps each time it wakes.
+func (s *Service) ListPolicies(ctx context.Context) ([]Policy, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT org_id, table_name, duration_seconds, max_rows, enabled, created_at, updated_at
+		 FROM retention_policies`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []Policy
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// DeletePolicy removes the policy for (orgID, tableName).
+func (s *Service) DeletePolicy(ctx context.Context, orgID, tableName string) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM retention_policies WHERE org_id = ? AND table_name = ?",
+		orgID, tableName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return httperr.ErrNotFound("retention policy not found")
+	}
+
+	return nil
+}
+
+func scanPolicy(scanner interface{ Scan(...interface{}) error }) (*Policy, error) {
+	var policy Policy
+	var createdAt, updatedAt string
+	var maxRows sql.NullInt64
+
+	err := scanner.Scan(
+		&policy.OrgID, &policy.TableName, &policy.DurationSeconds, &maxRows, &policy.Enabled,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxRows.Valid {
+		policy.MaxRows = &maxRows.Int64
+	}
+	policy.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	policy.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+
+	return &policy, nil
+}
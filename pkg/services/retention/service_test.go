@@ -0,0 +1,121 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"constellation-api/db"
+	"constellation-api/pkg/shared/httperr"
+)
+
+// wantCode reports whether err is an *httperr.APIError with the given code.
+func wantCode(err error, code string) bool {
+	var apiErr *httperr.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func newTestDB(t *testing.T) *db.Service {
+	t.Helper()
+
+	cfg := db.DefaultConfig()
+	cfg.DBPath = filepath.Join(t.TempDir(), "constellation.db")
+
+	svc, err := db.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create db service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	return svc
+}
+
+func TestTimeColumn(t *testing.T) {
+	if col, ok := TimeColumn("entities"); !ok || col != "created_at" {
+		t.Errorf("TimeColumn(entities) = (%q, %v), want (created_at, true)", col, ok)
+	}
+	if _, ok := TimeColumn("not_a_table"); ok {
+		t.Error("TimeColumn(not_a_table) = true, want false")
+	}
+}
+
+func TestUpsertPolicyRejectsUnsupportedTable(t *testing.T) {
+	s := NewService(newTestDB(t).DB)
+	ctx := context.Background()
+
+	err := s.UpsertPolicy(ctx, &Policy{OrgID: "org-1", TableName: "not_a_table", DurationSeconds: 60})
+	if !wantCode(err, "VALIDATION_FAILED") {
+		t.Fatalf("UpsertPolicy with unsupported table = %v, want a validation error", err)
+	}
+}
+
+func TestUpsertPolicyRejectsNonPositiveDuration(t *testing.T) {
+	s := NewService(newTestDB(t).DB)
+	ctx := context.Background()
+
+	err := s.UpsertPolicy(ctx, &Policy{OrgID: "org-1", TableName: "entities", DurationSeconds: 0})
+	if !wantCode(err, "VALIDATION_FAILED") {
+		t.Fatalf("UpsertPolicy with zero duration = %v, want a validation error", err)
+	}
+}
+
+func TestUpsertGetDeletePolicy(t *testing.T) {
+	s := NewService(newTestDB(t).DB)
+	ctx := context.Background()
+
+	maxRows := int64(1000)
+	policy := &Policy{
+		OrgID:           "org-1",
+		TableName:       "entities",
+		DurationSeconds: 3600,
+		MaxRows:         &maxRows,
+		Enabled:         true,
+	}
+	if err := s.UpsertPolicy(ctx, policy); err != nil {
+		t.Fatalf("UpsertPolicy failed: %v", err)
+	}
+
+	got, err := s.GetPolicy(ctx, "org-1", "entities")
+	if err != nil {
+		t.Fatalf("GetPolicy failed: %v", err)
+	}
+	if got.DurationSeconds != 3600 || got.MaxRows == nil || *got.MaxRows != 1000 || !got.Enabled {
+		t.Errorf("GetPolicy = %+v, want duration=3600 max_rows=1000 enabled=true", got)
+	}
+
+	// Upsert again with a different duration - same (org, table) key should
+	// update in place rather than create a second row.
+	policy.DurationSeconds = 7200
+	if err := s.UpsertPolicy(ctx, policy); err != nil {
+		t.Fatalf("second UpsertPolicy failed: %v", err)
+	}
+
+	policies, err := s.ListPolicies(ctx)
+	if err != nil {
+		t.Fatalf("ListPolicies failed: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("ListPolicies returned %d policies, want 1", len(policies))
+	}
+	if policies[0].DurationSeconds != 7200 {
+		t.Errorf("ListPolicies[0].DurationSeconds = %d, want 7200", policies[0].DurationSeconds)
+	}
+
+	if err := s.DeletePolicy(ctx, "org-1", "entities"); err != nil {
+		t.Fatalf("DeletePolicy failed: %v", err)
+	}
+
+	if _, err := s.GetPolicy(ctx, "org-1", "entities"); !wantCode(err, "NOT_FOUND") {
+		t.Errorf("GetPolicy after delete = %v, want a not-found error", err)
+	}
+}
+
+func TestDeletePolicyNotFound(t *testing.T) {
+	s := NewService(newTestDB(t).DB)
+
+	err := s.DeletePolicy(context.Background(), "org-1", "entities")
+	if !wantCode(err, "NOT_FOUND") {
+		t.Fatalf("DeletePolicy for nonexistent policy = %v, want a not-found error", err)
+	}
+}
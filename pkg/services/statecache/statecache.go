@@ -0,0 +1,167 @@
+// Package statecache gives consumers the latest known state of an entity
+// without replaying the JetStream entities stream from the start, and a
+// TTL'd presence roster derived from telemetry, backed by JetStream
+// Key-Value (see EmbeddedNATS.CreateStateCacheBuckets for bucket setup).
+package statecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/httperr"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Presence is the value stored in the presence bucket: an entity's last
+// reported status and when it was last heard from. The bucket's TTL expires
+// the key if no telemetry refreshes it, so a missing roster entry already
+// means "offline" without a separate status value.
+type Presence struct {
+	Status   string    `json:"status"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Entry is a KV lookup result: the stored JSON value and the revision it was
+// written at, so callers (e.g. the entity state HTTP endpoint) can support
+// conditional GETs via If-None-Match.
+type Entry struct {
+	Data     json.RawMessage
+	Revision uint64
+}
+
+// Service reads and writes the entity-state and presence KV buckets.
+type Service struct {
+	entities nats.KeyValue
+	presence nats.KeyValue
+}
+
+// NewService binds to the entity-state and presence KV buckets.
+// EmbeddedNATS.CreateStateCacheBuckets must have already created them (e.g.
+// at startup, alongside CreateConstellationStreams) or this fails.
+func NewService(natsClient *embeddednats.EmbeddedNATS) (*Service, error) {
+	js := natsClient.JetStream()
+	if js == nil {
+		return nil, fmt.Errorf("JetStream not initialized")
+	}
+
+	entities, err := js.KeyValue(shared.KVBucketEntities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind entity state bucket: %w", err)
+	}
+	presence, err := js.KeyValue(shared.KVBucketPresence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind presence bucket: %w", err)
+	}
+
+	return &Service{entities: entities, presence: presence}, nil
+}
+
+// stateKey is the KV key for an org's entity: "<org_id>/<entity_id>".
+func stateKey(orgID, entityID string) string {
+	return orgID + "/" + entityID
+}
+
+// UpsertEntity stores data as the latest known state for entityID, e.g. the
+// CloudEvents payload from an entity create/update/delete/status event.
+func (s *Service) UpsertEntity(orgID, entityID string, data interface{}) (uint64, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal entity state: %w", err)
+	}
+	return s.entities.Put(stateKey(orgID, entityID), raw)
+}
+
+// GetEntity returns the latest known state for entityID.
+func (s *Service) GetEntity(orgID, entityID string) (*Entry, error) {
+	entry, err := s.entities.Get(stateKey(orgID, entityID))
+	if err != nil {
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, httperr.ErrNotFound("no known state for entity: " + entityID)
+		}
+		return nil, httperr.ErrInternal(err)
+	}
+	return &Entry{Data: entry.Value(), Revision: entry.Revision()}, nil
+}
+
+// RefreshPresence records status as entityID's current state and resets the
+// presence bucket's TTL, so the entity keeps appearing on the roster for as
+// long as telemetry keeps arriving.
+func (s *Service) RefreshPresence(orgID, entityID, status string) error {
+	data, err := json.Marshal(Presence{Status: status, LastSeen: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal presence: %w", err)
+	}
+	_, err = s.presence.Put(stateKey(orgID, entityID), data)
+	return err
+}
+
+// ListPresence returns the live roster for orgID: every entity with a
+// non-expired presence record, keyed by entity ID.
+func (s *Service) ListPresence(orgID string) (map[string]Presence, error) {
+	keys, err := s.presence.ListKeys()
+	if err != nil {
+		if errors.Is(err, nats.ErrNoKeysFound) {
+			return map[string]Presence{}, nil
+		}
+		return nil, fmt.Errorf("failed to list presence keys: %w", err)
+	}
+	defer keys.Stop()
+
+	prefix := orgID + "/"
+	roster := make(map[string]Presence)
+	for key := range keys.Keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		entry, err := s.presence.Get(key)
+		if err != nil {
+			continue // expired or deleted between list and get
+		}
+
+		var p Presence
+		if err := json.Unmarshal(entry.Value(), &p); err != nil {
+			continue
+		}
+		roster[strings.TrimPrefix(key, prefix)] = p
+	}
+	return roster, nil
+}
+
+// Watch streams entity state for orgID to handle: first the current value
+// of every known entity (a snapshot, delivered as WatchAll replays the
+// bucket's latest values), then every subsequent update, until ctx is done.
+// It's the feed the SSE subsystem uses to give a new client a full roster
+// before switching to deltas.
+func (s *Service) Watch(ctx context.Context, orgID string, handle func(entry nats.KeyValueEntry)) error {
+	watcher, err := s.entities.WatchAll(nats.Context(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to watch entity state: %w", err)
+	}
+	defer watcher.Stop()
+
+	prefix := orgID + "/"
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case entry, ok := <-watcher.Updates():
+			if !ok {
+				return nil
+			}
+			if entry == nil {
+				continue // marks the end of the initial snapshot
+			}
+			if strings.HasPrefix(entry.Key(), prefix) {
+				handle(entry)
+			}
+		}
+	}
+}
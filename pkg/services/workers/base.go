@@ -2,18 +2,60 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strconv"
+	"sync"
 	"time"
 
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
 	"github.com/nats-io/nats.go"
 )
 
+// ErrPermanent marks a handler error as non-retryable: dispatch Term's the
+// message and republishes it to CONSTELLATION_DLQ immediately, instead of
+// Nak'ing it to wait for redelivery. Handlers should wrap it with %w, e.g.
+// fmt.Errorf("entity %s not found: %w", id, ErrPermanent).
+var ErrPermanent = errors.New("permanent worker error")
+
 type Worker interface {
 	Start(ctx context.Context) error
 	Stop() error
 	Name() string
 }
 
+// WorkerConfig tunes a BaseWorker's consumer and dispatch behavior.
+type WorkerConfig struct {
+	// Concurrency bounds how many fetched messages a worker hands to
+	// handlers at once; additional messages wait for a free slot.
+	Concurrency int
+	// MaxDeliver is the JetStream consumer's max redelivery count. A message
+	// still failing once it's been delivered this many times is Term'd and
+	// dead-lettered rather than redelivered again.
+	MaxDeliver int
+	// AckWait is the JetStream consumer's ack deadline. A handler still
+	// running past half of AckWait gets its message's InProgress heartbeat
+	// renewed so JetStream doesn't redeliver out from under it.
+	AckWait time.Duration
+	// BackOff is the redelivery backoff JetStream applies between attempts,
+	// and also what nakWithBackoff mirrors when a handler error is
+	// redelivered under manual Nak control (e.g. CommandWorker). Indexed by
+	// delivery count; the last entry is reused for any delivery beyond it.
+	BackOff []time.Duration
+}
+
+// DefaultWorkerConfig returns the WorkerConfig every worker uses unless the
+// caller overrides it.
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Concurrency: 4,
+		MaxDeliver:  5,
+		AckWait:     30 * time.Second,
+		BackOff:     []time.Duration{1 * time.Second, 5 * time.Second, 15 * time.Second, 30 * time.Second, 30 * time.Second},
+	}
+}
+
 type BaseWorker struct {
 	name     string
 	nc       *nats.Conn
@@ -22,9 +64,10 @@ type BaseWorker struct {
 	consumer string
 	stream   string
 	subject  string
+	config   WorkerConfig
 }
 
-func NewBaseWorker(name string, nc *nats.Conn, js nats.JetStreamContext, stream, consumer, subject string) *BaseWorker {
+func NewBaseWorker(name string, nc *nats.Conn, js nats.JetStreamContext, stream, consumer, subject string, config WorkerConfig) *BaseWorker {
 	return &BaseWorker{
 		name:     name,
 		nc:       nc,
@@ -32,6 +75,7 @@ func NewBaseWorker(name string, nc *nats.Conn, js nats.JetStreamContext, stream,
 		consumer: consumer,
 		stream:   stream,
 		subject:  subject,
+		config:   config,
 	}
 }
 
@@ -46,12 +90,20 @@ func (w *BaseWorker) Stop() error {
 	return nil
 }
 
-func (w *BaseWorker) processMessages(ctx context.Context, handler func(*nats.Msg)) error {
-	sub, err := w.js.PullSubscribe(w.subject, "", 
+// fetchLoop pull-subscribes to the worker's stream/consumer and repeatedly
+// fetches batches of messages until ctx is done, dispatching each to handle
+// on one of config.Concurrency worker goroutines. Unlike processMessages,
+// handle is responsible for its own Ack/Nak/Term - CommandWorker needs that
+// control to distinguish transient failures from unknown command types.
+func (w *BaseWorker) fetchLoop(ctx context.Context, handle func(*nats.Msg)) error {
+	sub, err := w.js.PullSubscribe(w.subject, "",
 		nats.Durable(w.consumer),
 		nats.ManualAck(),
 		nats.AckExplicit(),
 		nats.DeliverAll(),
+		nats.MaxDeliver(w.config.MaxDeliver),
+		nats.AckWait(w.config.AckWait),
+		nats.BackOff(w.config.BackOff),
 		nats.Bind(w.stream, w.consumer),
 	)
 	if err != nil {
@@ -59,12 +111,16 @@ func (w *BaseWorker) processMessages(ctx context.Context, handler func(*nats.Msg
 	}
 	w.sub = sub
 
-	log.Printf("[%s] Starting worker for stream: %s, consumer: %s", w.name, w.stream, w.consumer)
+	log.Printf("[%s] Starting worker for stream: %s, consumer: %s, concurrency: %d", w.name, w.stream, w.consumer, w.config.Concurrency)
+
+	sem := make(chan struct{}, w.config.Concurrency)
+	var wg sync.WaitGroup
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Printf("[%s] Worker stopping", w.name)
+			wg.Wait()
 			return ctx.Err()
 		default:
 			msgs, err := sub.Fetch(10, nats.MaxWait(2*time.Second))
@@ -74,11 +130,141 @@ func (w *BaseWorker) processMessages(ctx context.Context, handler func(*nats.Msg
 			}
 
 			for _, msg := range msgs {
-				handler(msg)
-				if err := msg.Ack(); err != nil {
-					log.Printf("[%s] Error acknowledging message: %v", w.name, err)
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(m *nats.Msg) {
+					defer wg.Done()
+					defer func() { <-sem }()
+					handle(m)
+				}(msg)
+			}
+		}
+	}
+}
+
+// processMessages decodes each fetched message as a CloudEvents envelope
+// (see pkg/shared/cloudevents) and hands the typed *cloudevents.Event to
+// handler, instead of the raw *nats.Msg - every publisher on these streams
+// now writes CloudEvents envelopes, so this is the one place that needs to
+// know about structured vs binary mode. handler's returned error decides how
+// the message is settled: see settle.
+func (w *BaseWorker) processMessages(ctx context.Context, handler func(context.Context, *cloudevents.Event) error) error {
+	return w.fetchLoop(ctx, func(msg *nats.Msg) {
+		w.runWithHeartbeat(msg, func() error {
+			event, err := cloudevents.Decode(msg)
+			if err != nil {
+				return errors.Join(ErrPermanent, err)
+			}
+			return handler(ctx, event)
+		})
+	})
+}
+
+// runWithHeartbeat runs fn, renewing msg's ack deadline via InProgress every
+// half of config.AckWait for as long as fn is still running, then settles
+// msg according to fn's result.
+func (w *BaseWorker) runWithHeartbeat(msg *nats.Msg, fn func() error) {
+	stop := make(chan struct{})
+	var hbWg sync.WaitGroup
+	hbWg.Add(1)
+	go func() {
+		defer hbWg.Done()
+		ticker := time.NewTicker(w.config.AckWait / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := msg.InProgress(); err != nil {
+					log.Printf("[%s] Error renewing in-progress heartbeat: %v", w.name, err)
 				}
 			}
 		}
+	}()
+
+	err := fn()
+	close(stop)
+	hbWg.Wait()
+
+	w.settle(msg, err)
+}
+
+// settle acks a successfully handled message, or - on error - Term's it and
+// republishes to CONSTELLATION_DLQ if it's a permanent error (see
+// ErrPermanent) or has already exhausted config.MaxDeliver, else Nak's it
+// with the backoff config.BackOff assigns to its delivery count.
+func (w *BaseWorker) settle(msg *nats.Msg, err error) {
+	if err == nil {
+		if ackErr := msg.Ack(); ackErr != nil {
+			log.Printf("[%s] Error acknowledging message: %v", w.name, ackErr)
+		}
+		return
+	}
+
+	deliveries := 1
+	if meta, metaErr := msg.Metadata(); metaErr == nil {
+		deliveries = int(meta.NumDelivered)
 	}
-}
\ No newline at end of file
+
+	if errors.Is(err, ErrPermanent) || deliveries >= w.config.MaxDeliver {
+		log.Printf("[%s] Dead-lettering message on subject %s after %d deliveries: %v", w.name, msg.Subject, deliveries, err)
+		w.publishToDLQ(msg, deliveries, err)
+		w.term(msg)
+		return
+	}
+
+	log.Printf("[%s] Handler error on subject %s (delivery %d): %v", w.name, msg.Subject, deliveries, err)
+	w.nakWithBackoff(msg, deliveries)
+}
+
+// term terminates msg, telling JetStream not to redeliver it.
+func (w *BaseWorker) term(msg *nats.Msg) {
+	if err := msg.Term(); err != nil {
+		log.Printf("[%s] Error terminating message: %v", w.name, err)
+	}
+}
+
+// nakWithBackoff redelivers msg after the delay config.BackOff assigns to
+// deliveries, clamping to the last entry for any delivery beyond its length.
+func (w *BaseWorker) nakWithBackoff(msg *nats.Msg, deliveries int) {
+	if err := msg.NakWithDelay(backoffFor(w.config.BackOff, deliveries)); err != nil {
+		log.Printf("[%s] Error nak'ing message: %v", w.name, err)
+	}
+}
+
+// backoffFor returns the delay for a message on its nth delivery, clamping
+// to the last entry of backoff once deliveries exceeds it.
+func backoffFor(backoff []time.Duration, deliveries int) time.Duration {
+	if len(backoff) == 0 {
+		return 0
+	}
+	idx := deliveries - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(backoff) {
+		idx = len(backoff) - 1
+	}
+	return backoff[idx]
+}
+
+// publishToDLQ republishes msg to CONSTELLATION_DLQ unchanged except for
+// headers recording where it came from: its original subject, how many
+// times it was delivered, and the error that finally gave up on it.
+func (w *BaseWorker) publishToDLQ(msg *nats.Msg, deliveries int, lastErr error) {
+	dlqMsg := nats.NewMsg(shared.DLQSubject(w.stream))
+	for key, values := range msg.Header {
+		for _, value := range values {
+			dlqMsg.Header.Add(key, value)
+		}
+	}
+	dlqMsg.Header.Set("Dlq-Original-Subject", msg.Subject)
+	dlqMsg.Header.Set("Dlq-Delivery-Count", strconv.Itoa(deliveries))
+	dlqMsg.Header.Set("Dlq-Last-Error", lastErr.Error())
+	dlqMsg.Data = msg.Data
+
+	if _, err := w.js.PublishMsg(dlqMsg); err != nil {
+		log.Printf("[%s] Failed to publish to DLQ: %v", w.name, err)
+	}
+}
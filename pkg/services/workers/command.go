@@ -2,18 +2,50 @@ package workers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
-	"constellation-overwatch/pkg/shared"
+	"constellation-api/pkg/ontology"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 )
 
+// defaultCommandTimeout bounds how long a handler may run when the command
+// itself doesn't set a Deadline.
+const defaultCommandTimeout = 30 * time.Second
+
+// CommandHandler executes one command type (e.g. "entity.update_status")
+// and returns the result to publish back to the caller.
+type CommandHandler interface {
+	Handle(ctx context.Context, cmd shared.Command) (shared.CommandResult, error)
+}
+
+// CommandHandlerFunc adapts a plain function to CommandHandler.
+type CommandHandlerFunc func(ctx context.Context, cmd shared.Command) (shared.CommandResult, error)
+
+func (f CommandHandlerFunc) Handle(ctx context.Context, cmd shared.Command) (shared.CommandResult, error) {
+	return f(ctx, cmd)
+}
+
+// CommandWorker dispatches shared.Command messages from the commands stream
+// to the CommandHandler registered for their Type, and - if the command has
+// a reply subject - publishes a shared.CommandResult back to it.
 type CommandWorker struct {
 	*BaseWorker
+	handlers map[string]CommandHandler
 }
 
-func NewCommandWorker(nc *nats.Conn, js nats.JetStreamContext) *CommandWorker {
+// NewCommandWorker builds a CommandWorker. handlers is keyed by command type
+// (e.g. "entity.update_status", "entity.move", "mission.assign"); a command
+// whose Type has no registered handler is Term'd rather than retried.
+func NewCommandWorker(nc *nats.Conn, js nats.JetStreamContext, handlers map[string]CommandHandler, config WorkerConfig) *CommandWorker {
+	if handlers == nil {
+		handlers = make(map[string]CommandHandler)
+	}
 	return &CommandWorker{
 		BaseWorker: NewBaseWorker(
 			"CommandWorker",
@@ -22,20 +54,144 @@ func NewCommandWorker(nc *nats.Conn, js nats.JetStreamContext) *CommandWorker {
 			shared.StreamCommands,
 			shared.ConsumerCommandProcessor,
 			shared.SubjectCommandsAll,
+			config,
 		),
+		handlers: handlers,
 	}
 }
 
 func (w *CommandWorker) Start(ctx context.Context) error {
-	return w.processMessages(ctx, func(msg *nats.Msg) {
-		log.Printf("[%s] Received command message on subject: %s", w.Name(), msg.Subject)
-		
-		var data map[string]interface{}
-		if err := json.Unmarshal(msg.Data, &data); err != nil {
-			log.Printf("[%s] Raw message data: %s", w.Name(), string(msg.Data))
+	return w.fetchLoop(ctx, func(msg *nats.Msg) {
+		w.dispatch(ctx, msg)
+	})
+}
+
+// dispatch decodes msg's CloudEvents envelope (see pkg/shared/cloudevents)
+// into a shared.Command and runs its handler under a deadline, then hands
+// the result to BaseWorker.settle: an unparseable envelope/payload or an
+// unknown command type is wrapped in ErrPermanent (Term + DLQ, retrying
+// either would just loop forever), a handler error is retried with the
+// consumer's redelivery backoff, and success Acks.
+func (w *CommandWorker) dispatch(ctx context.Context, msg *nats.Msg) {
+	w.runWithHeartbeat(msg, func() error {
+		event, err := cloudevents.Decode(msg)
+		if err != nil {
+			return errors.Join(ErrPermanent, fmt.Errorf("unparseable cloudevents envelope: %w", err))
+		}
+
+		var cmd shared.Command
+		if err := event.DataAs(&cmd); err != nil {
+			return errors.Join(ErrPermanent, fmt.Errorf("unparseable command: %w", err))
+		}
+
+		handler, ok := w.handlers[cmd.Type]
+		if !ok {
+			return errors.Join(ErrPermanent, fmt.Errorf("no handler registered for command type %q", cmd.Type))
+		}
+
+		handlerCtx := ctx
+		var cancel context.CancelFunc
+		if cmd.Deadline != nil {
+			handlerCtx, cancel = context.WithDeadline(ctx, *cmd.Deadline)
 		} else {
-			prettyJSON, _ := json.MarshalIndent(data, "", "  ")
-			log.Printf("[%s] Command data:\n%s", w.Name(), string(prettyJSON))
+			handlerCtx, cancel = context.WithTimeout(ctx, defaultCommandTimeout)
+		}
+		defer cancel()
+
+		result, err := handler.Handle(handlerCtx, cmd)
+		if err != nil {
+			log.Printf("[%s] Command %s (%s) failed: %v", w.Name(), cmd.ID, cmd.Type, err)
+			w.publishResult(msg, cmd, shared.CommandResult{ID: cmd.ID, Status: shared.CommandStatusError, Error: err.Error()})
+			return err
+		}
+
+		if result.ID == "" {
+			result.ID = cmd.ID
+		}
+		if result.Status == "" {
+			result.Status = shared.CommandStatusOK
 		}
+		w.publishResult(msg, cmd, result)
+		return nil
 	})
-}
\ No newline at end of file
+}
+
+// publishResult replies on msg.Reply (NATS core request/reply) if set, else
+// on cmd.ReplyTo (an ordinary subject, for callers not using request/reply
+// semantics); it's a no-op if neither is set. The reply is a core NATS
+// publish rather than a JetStream-stored message, so it's encoded in binary
+// mode - the envelope attributes go in Ce-* headers and the body stays plain
+// CommandResult JSON, which is what a caller using nc.Request would expect.
+func (w *CommandWorker) publishResult(msg *nats.Msg, cmd shared.Command, result shared.CommandResult) {
+	replyTo := msg.Reply
+	if replyTo == "" {
+		replyTo = cmd.ReplyTo
+	}
+	if replyTo == "" {
+		return
+	}
+
+	source := fmt.Sprintf("urn:constellation:org:%s:command:%s", cmd.OrgID, cmd.ID)
+	event, err := cloudevents.New(uuid.New().String(), source, "com.constellation.command."+cmd.Type+".result", cmd.Target, result)
+	if err != nil {
+		log.Printf("[%s] Failed to build result cloudevent for command %s: %v", w.Name(), cmd.ID, err)
+		return
+	}
+
+	reply := nats.NewMsg(replyTo)
+	if err := cloudevents.Encode(reply, event, cloudevents.ModeBinary); err != nil {
+		log.Printf("[%s] Failed to encode result cloudevent for command %s: %v", w.Name(), cmd.ID, err)
+		return
+	}
+
+	if err := w.nc.PublishMsg(reply); err != nil {
+		log.Printf("[%s] Failed to publish result for command %s: %v", w.Name(), cmd.ID, err)
+	}
+}
+
+// EntityUpdater is the slice of EntityService the built-in command handlers
+// need, kept narrow so this package doesn't import api/services (and its
+// NATS/hook dependencies) just to dispatch commands.
+type EntityUpdater interface {
+	UpdateEntity(ctx context.Context, orgID, entityID string, updates map[string]interface{}, ifMatchVersion *int64) (*ontology.Entity, error)
+	UpdateEntityStatus(ctx context.Context, orgID, entityID, status string) error
+}
+
+// EntityCommandHandlers returns the built-in CommandHandlers that give the
+// REST entity-update surface a NATS-driven equivalent: "entity.update_status"
+// and "entity.move" both delegate to the same EntityService the HTTP
+// handlers use.
+func EntityCommandHandlers(entities EntityUpdater) map[string]CommandHandler {
+	return map[string]CommandHandler{
+		"entity.update_status": CommandHandlerFunc(func(ctx context.Context, cmd shared.Command) (shared.CommandResult, error) {
+			status, _ := cmd.Payload["status"].(string)
+			if cmd.Target == "" || status == "" {
+				return shared.CommandResult{}, fmt.Errorf("entity.update_status requires target and payload.status")
+			}
+			if err := entities.UpdateEntityStatus(ctx, cmd.OrgID, cmd.Target, status); err != nil {
+				return shared.CommandResult{}, err
+			}
+			return shared.CommandResult{Status: shared.CommandStatusOK}, nil
+		}),
+		"entity.move": CommandHandlerFunc(func(ctx context.Context, cmd shared.Command) (shared.CommandResult, error) {
+			if cmd.Target == "" {
+				return shared.CommandResult{}, fmt.Errorf("entity.move requires target")
+			}
+			updates := make(map[string]interface{})
+			for _, key := range []string{"latitude", "longitude", "altitude", "heading", "velocity"} {
+				if v, ok := cmd.Payload[key]; ok {
+					updates[key] = v
+				}
+			}
+			if len(updates) == 0 {
+				return shared.CommandResult{}, fmt.Errorf("entity.move requires at least one of latitude/longitude/altitude/heading/velocity in payload")
+			}
+
+			entity, err := entities.UpdateEntity(ctx, cmd.OrgID, cmd.Target, updates, nil)
+			if err != nil {
+				return shared.CommandResult{}, err
+			}
+			return shared.CommandResult{Status: shared.CommandStatusOK, Data: entity}, nil
+		}),
+	}
+}
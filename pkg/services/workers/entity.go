@@ -5,15 +5,18 @@ import (
 	"encoding/json"
 	"log"
 
+	"constellation-api/pkg/services/statecache"
 	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
 	"github.com/nats-io/nats.go"
 )
 
 type EntityWorker struct {
 	*BaseWorker
+	stateCache *statecache.Service
 }
 
-func NewEntityWorker(nc *nats.Conn, js nats.JetStreamContext) *EntityWorker {
+func NewEntityWorker(nc *nats.Conn, js nats.JetStreamContext, config WorkerConfig, stateCache *statecache.Service) *EntityWorker {
 	return &EntityWorker{
 		BaseWorker: NewBaseWorker(
 			"EntityWorker",
@@ -22,20 +25,49 @@ func NewEntityWorker(nc *nats.Conn, js nats.JetStreamContext) *EntityWorker {
 			shared.StreamEntities,
 			shared.ConsumerEntityProcessor,
 			shared.SubjectEntitiesAll,
+			config,
 		),
+		stateCache: stateCache,
 	}
 }
 
 func (w *EntityWorker) Start(ctx context.Context) error {
-	return w.processMessages(ctx, func(msg *nats.Msg) {
-		log.Printf("[%s] Received entity message on subject: %s", w.Name(), msg.Subject)
-		
+	return w.processMessages(ctx, func(ctx context.Context, event *cloudevents.Event) error {
+		log.Printf("[%s] Received %s event on subject: %s", w.Name(), event.Type, event.Subject)
+
 		var data map[string]interface{}
-		if err := json.Unmarshal(msg.Data, &data); err != nil {
-			log.Printf("[%s] Raw message data: %s", w.Name(), string(msg.Data))
-		} else {
-			prettyJSON, _ := json.MarshalIndent(data, "", "  ")
-			log.Printf("[%s] Entity data:\n%s", w.Name(), string(prettyJSON))
+		if err := event.DataAs(&data); err != nil {
+			log.Printf("[%s] Raw event data: %s", w.Name(), string(event.Data))
+			return nil
 		}
+
+		prettyJSON, _ := json.MarshalIndent(data, "", "  ")
+		log.Printf("[%s] Entity data:\n%s", w.Name(), string(prettyJSON))
+
+		w.upsertState(data)
+		return nil
 	})
-}
\ No newline at end of file
+}
+
+// upsertState writes the entity event's payload into the entity state KV
+// bucket, so a consumer coming online later can look up the latest known
+// state instead of replaying the whole stream. org_id/entity_id come from
+// the event payload (see EntityService.publishEntityEvent), not the event
+// envelope; an event whose payload lacks them (e.g. blob_added) is logged
+// and skipped.
+func (w *EntityWorker) upsertState(data map[string]interface{}) {
+	if w.stateCache == nil {
+		return
+	}
+
+	orgID, _ := data["org_id"].(string)
+	entityID, _ := data["entity_id"].(string)
+	if orgID == "" || entityID == "" {
+		log.Printf("[%s] Entity event missing org_id/entity_id, skipping state cache upsert", w.Name())
+		return
+	}
+
+	if _, err := w.stateCache.UpsertEntity(orgID, entityID, data); err != nil {
+		log.Printf("[%s] Failed to upsert entity state for %s/%s: %v", w.Name(), orgID, entityID, err)
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"encoding/json"
 	"log"
 
-	"constellation-overwatch/pkg/shared"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
 	"github.com/nats-io/nats.go"
 )
 
@@ -13,7 +14,7 @@ type EventWorker struct {
 	*BaseWorker
 }
 
-func NewEventWorker(nc *nats.Conn, js nats.JetStreamContext) *EventWorker {
+func NewEventWorker(nc *nats.Conn, js nats.JetStreamContext, config WorkerConfig) *EventWorker {
 	return &EventWorker{
 		BaseWorker: NewBaseWorker(
 			"EventWorker",
@@ -22,20 +23,22 @@ func NewEventWorker(nc *nats.Conn, js nats.JetStreamContext) *EventWorker {
 			shared.StreamEvents,
 			shared.ConsumerEventProcessor,
 			shared.SubjectEventsAll,
+			config,
 		),
 	}
 }
 
 func (w *EventWorker) Start(ctx context.Context) error {
-	return w.processMessages(ctx, func(msg *nats.Msg) {
-		log.Printf("[%s] Received event message on subject: %s", w.Name(), msg.Subject)
-		
+	return w.processMessages(ctx, func(ctx context.Context, event *cloudevents.Event) error {
+		log.Printf("[%s] Received %s event on subject: %s", w.Name(), event.Type, event.Subject)
+
 		var data map[string]interface{}
-		if err := json.Unmarshal(msg.Data, &data); err != nil {
-			log.Printf("[%s] Raw message data: %s", w.Name(), string(msg.Data))
+		if err := event.DataAs(&data); err != nil {
+			log.Printf("[%s] Raw event data: %s", w.Name(), string(event.Data))
 		} else {
 			prettyJSON, _ := json.MarshalIndent(data, "", "  ")
 			log.Printf("[%s] Event data:\n%s", w.Name(), string(prettyJSON))
 		}
+		return nil
 	})
-}
\ No newline at end of file
+}
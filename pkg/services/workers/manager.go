@@ -6,7 +6,10 @@ import (
 	"log"
 	"sync"
 
+	"constellation-api/db"
 	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/services/retention"
+	"constellation-api/pkg/services/statecache"
 	"github.com/nats-io/nats.go"
 )
 
@@ -19,7 +22,12 @@ type Manager struct {
 	cancel  context.CancelFunc
 }
 
-func NewManager(natsClient *embeddednats.EmbeddedNATS) (*Manager, error) {
+// NewManager wires up the stream workers. entities is the same EntityService
+// instance the HTTP API uses - CommandWorker's entity.update_status/entity.move
+// handlers dispatch through it, so hooks registered on it (see
+// services.EntityService.RegisterHook) fire for both write paths rather than
+// only the HTTP one.
+func NewManager(natsClient *embeddednats.EmbeddedNATS, dbService *db.Service, entities EntityUpdater) (*Manager, error) {
 	nc := natsClient.Connection()
 	if nc == nil {
 		return nil, fmt.Errorf("NATS connection not initialized")
@@ -32,16 +40,26 @@ func NewManager(natsClient *embeddednats.EmbeddedNATS) (*Manager, error) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	retentionPolicies := retention.NewService(dbService.GetDB())
+	workerConfig := DefaultWorkerConfig()
+
+	stateCache, err := statecache.NewService(natsClient)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to bind state cache: %w", err)
+	}
+
 	return &Manager{
 		nc:     nc,
 		js:     js,
 		ctx:    ctx,
 		cancel: cancel,
 		workers: []Worker{
-			NewTelemetryWorker(nc, js),
-			NewEntityWorker(nc, js),
-			NewEventWorker(nc, js),
-			NewCommandWorker(nc, js),
+			NewTelemetryWorker(nc, js, workerConfig, stateCache),
+			NewEntityWorker(nc, js, workerConfig, stateCache),
+			NewEventWorker(nc, js, workerConfig),
+			NewCommandWorker(nc, js, EntityCommandHandlers(entities), workerConfig),
+			NewRetentionWorker(dbService, retentionPolicies, natsClient, DefaultRetentionInterval),
 		},
 	}, nil
 }
@@ -0,0 +1,135 @@
+package workers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"constellation-api/db"
+	"constellation-api/pkg/ontology"
+	embeddednats "constellation-api/pkg/services/embedded-nats"
+
+	"github.com/nats-io/nats.go"
+)
+
+// stubEntityUpdater is a no-op EntityUpdater, just enough to satisfy
+// NewManager for a startup smoke test - CommandWorker's handlers are never
+// exercised here.
+type stubEntityUpdater struct{}
+
+func (stubEntityUpdater) UpdateEntity(ctx context.Context, orgID, entityID string, updates map[string]interface{}, ifMatchVersion *int64) (*ontology.Entity, error) {
+	return nil, nil
+}
+
+func (stubEntityUpdater) UpdateEntityStatus(ctx context.Context, orgID, entityID, status string) error {
+	return nil
+}
+
+// newTestNATS starts an embedded NATS server on a random port under a
+// temporary data directory, with the constellation streams, state cache
+// buckets, and durable consumers every worker expects already created -
+// mirroring cmd/microlith/main.go's initNATS.
+func newTestNATS(t *testing.T) *embeddednats.EmbeddedNATS {
+	t.Helper()
+
+	cfg := embeddednats.DefaultConfig()
+	cfg.Port = 14222 // dedicated test port, distinct from the dev default
+	cfg.WSPort = 0
+	cfg.DataDir = filepath.Join(t.TempDir(), "nats")
+
+	nc, err := embeddednats.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create embedded NATS: %v", err)
+	}
+	if err := nc.Start(); err != nil {
+		t.Fatalf("failed to start embedded NATS: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = nc.Shutdown(context.Background())
+	})
+
+	if err := nc.CreateConstellationStreams(); err != nil {
+		t.Fatalf("failed to create constellation streams: %v", err)
+	}
+	if err := nc.CreateStateCacheBuckets(); err != nil {
+		t.Fatalf("failed to create state cache buckets: %v", err)
+	}
+
+	workerConfig := DefaultWorkerConfig()
+	consumers := []struct {
+		stream, consumer, filter string
+	}{
+		{"CONSTELLATION_ENTITIES", "entity-processor", "constellation.entities.>"},
+		{"CONSTELLATION_COMMANDS", "command-processor", "constellation.commands.>"},
+		{"CONSTELLATION_EVENTS", "event-processor", "constellation.events.>"},
+		{"CONSTELLATION_TELEMETRY", "telemetry-processor", "constellation.telemetry.>"},
+	}
+	for _, c := range consumers {
+		if err := nc.CreateDurableConsumer(c.stream, c.consumer, c.filter, workerConfig.MaxDeliver, workerConfig.AckWait); err != nil {
+			t.Fatalf("failed to create durable consumer %s: %v", c.consumer, err)
+		}
+	}
+
+	return nc
+}
+
+func newTestDB(t *testing.T) *db.Service {
+	t.Helper()
+
+	cfg := db.DefaultConfig()
+	cfg.DBPath = filepath.Join(t.TempDir(), "constellation.db")
+
+	svc, err := db.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create db service: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	return svc
+}
+
+// TestManagerStartStop is a start-up smoke test: it would have caught the
+// durable consumer MaxDeliver/AckWait mismatch between CreateDurableConsumer
+// and DefaultWorkerConfig, since every stream worker fails PullSubscribe
+// immediately when the two disagree.
+func TestManagerStartStop(t *testing.T) {
+	nc := newTestNATS(t)
+	dbService := newTestDB(t)
+
+	mgr, err := NewManager(nc, dbService, stubEntityUpdater{})
+	if err != nil {
+		t.Fatalf("NewManager failed: %v", err)
+	}
+
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// Give each worker's fetchLoop a moment to run PullSubscribe; a
+	// misconfigured consumer fails there, not in Start itself.
+	time.Sleep(500 * time.Millisecond)
+
+	for _, w := range mgr.workers {
+		var sub *nats.Subscription
+		switch worker := w.(type) {
+		case *EntityWorker:
+			sub = worker.BaseWorker.sub
+		case *TelemetryWorker:
+			sub = worker.BaseWorker.sub
+		case *EventWorker:
+			sub = worker.BaseWorker.sub
+		case *CommandWorker:
+			sub = worker.BaseWorker.sub
+		default:
+			continue // RetentionWorker isn't consumer-backed.
+		}
+		if sub == nil {
+			t.Errorf("worker %s never subscribed", w.Name())
+		}
+	}
+
+	if err := mgr.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"constellation-api/db"
+	embeddednats "constellation-api/pkg/services/embedded-nats"
+	"constellation-api/pkg/services/retention"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRetentionInterval is how often RetentionWorker sweeps retention
+// policies when the caller doesn't specify one.
+const DefaultRetentionInterval = 5 * time.Minute
+
+// retentionPublishTimeout bounds the detached NATS publish RetentionWorker
+// issues after each policy sweep, the same way EntityService bounds its own
+// event publishes rather than tying them to the sweep's context.
+const retentionPublishTimeout = 5 * time.Second
+
+// RetentionWorker wakes on a fixed interval, applies every enabled
+// retention_policies row, and publishes an EventTypePurged event per policy
+// that actually removed rows. Unlike the other workers it isn't driven by a
+// NATS consumer, so it implements Worker directly instead of embedding
+// BaseWorker.
+type RetentionWorker struct {
+	dbService *db.Service
+	policies  *retention.Service
+	nats      *embeddednats.EmbeddedNATS
+	interval  time.Duration
+	stopCh    chan struct{}
+}
+
+func NewRetentionWorker(dbService *db.Service, policies *retention.Service, nats *embeddednats.EmbeddedNATS, interval time.Duration) *RetentionWorker {
+	if interval <= 0 {
+		interval = DefaultRetentionInterval
+	}
+	return &RetentionWorker{
+		dbService: dbService,
+		policies:  policies,
+		nats:      nats,
+		interval:  interval,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func (w *RetentionWorker) Name() string {
+	return "RetentionWorker"
+}
+
+func (w *RetentionWorker) Start(ctx context.Context) error {
+	log.Printf("[%s] Starting worker, sweeping every %s", w.Name(), w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[%s] Worker stopping", w.Name())
+			return ctx.Err()
+		case <-w.stopCh:
+			return nil
+		case <-ticker.C:
+			if err := w.sweep(ctx); err != nil {
+				log.Printf("[%s] Sweep failed: %v", w.Name(), err)
+			}
+		}
+	}
+}
+
+func (w *RetentionWorker) Stop() error {
+	close(w.stopCh)
+	return nil
+}
+
+func (w *RetentionWorker) sweep(ctx context.Context) error {
+	policies, err := w.policies.ListPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list retention policies: %w", err)
+	}
+
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		if err := w.applyPolicy(ctx, policy); err != nil {
+			log.Printf("[%s] Policy %s/%s failed: %v", w.Name(), policy.OrgID, policy.TableName, err)
+		}
+	}
+
+	return nil
+}
+
+// applyPolicy deletes rows older than policy.DurationSeconds, then trims to
+// policy.MaxRows by ROWID if set, both inside a single Service.Transaction so
+// a sweep never leaves a table half-pruned.
+func (w *RetentionWorker) applyPolicy(ctx context.Context, policy retention.Policy) error {
+	timeColumn, ok := retention.TimeColumn(policy.TableName)
+	if !ok {
+		return fmt.Errorf("unsupported retention table: %s", policy.TableName)
+	}
+
+	var purged int64
+	err := w.dbService.Transaction(func(tx *sql.Tx) error {
+		cutoff := time.Now().Add(-time.Duration(policy.DurationSeconds) * time.Second).Format(time.RFC3339)
+
+		result, err := tx.ExecContext(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE org_id = ? AND %s < ?", policy.TableName, timeColumn),
+			policy.OrgID, cutoff,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to prune expired rows: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		purged += n
+
+		if policy.MaxRows != nil {
+			result, err = tx.ExecContext(ctx,
+				fmt.Sprintf(`DELETE FROM %s WHERE org_id = ? AND ROWID NOT IN (
+					SELECT ROWID FROM %s WHERE org_id = ? ORDER BY ROWID DESC LIMIT ?
+				)`, policy.TableName, policy.TableName),
+				policy.OrgID, policy.OrgID, *policy.MaxRows,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to trim to max_rows: %w", err)
+			}
+			n, _ = result.RowsAffected()
+			purged += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if purged > 0 {
+		w.publishPurged(policy, purged)
+	}
+
+	return nil
+}
+
+// publishPurged runs detached from the sweep's context, the same way
+// EntityService.publishEntityEvent runs detached from the request that
+// triggered it: the sweep may move on to the next policy before the publish
+// completes.
+func (w *RetentionWorker) publishPurged(policy retention.Policy, purged int64) {
+	if w.nats == nil || w.nats.JetStream() == nil {
+		log.Printf("[%s] NATS not available for publishing purge event", w.Name())
+		return
+	}
+
+	subject := shared.EventsPurgedSubject(policy.OrgID, policy.TableName)
+	source := fmt.Sprintf("urn:constellation:org:%s:retention:%s", policy.OrgID, policy.TableName)
+	data := map[string]interface{}{
+		"org_id":      policy.OrgID,
+		"table_name":  policy.TableName,
+		"purged_rows": purged,
+	}
+
+	event, err := cloudevents.New(uuid.New().String(), source, "com.constellation."+shared.EventTypePurged, policy.TableName, data)
+	if err != nil {
+		log.Printf("[%s] Failed to build purge cloudevent: %v", w.Name(), err)
+		return
+	}
+
+	msgID := fmt.Sprintf("%s-%s-purge-%d", policy.OrgID, policy.TableName, time.Now().UnixNano())
+
+	ctx, cancel := context.WithTimeout(context.Background(), retentionPublishTimeout)
+	defer cancel()
+
+	if err := w.nats.PublishEventWithDedup(ctx, subject, event, cloudevents.ModeStructured, msgID); err != nil {
+		log.Printf("[%s] Failed to publish purge event: %v", w.Name(), err)
+	} else {
+		log.Printf("[%s] Published purge event: %d row(s) from %s/%s", w.Name(), purged, policy.OrgID, policy.TableName)
+	}
+}
@@ -5,15 +5,23 @@ import (
 	"encoding/json"
 	"log"
 
-	"constellation-overwatch/pkg/shared"
+	"constellation-api/pkg/services/statecache"
+	"constellation-api/pkg/shared"
+	"constellation-api/pkg/shared/cloudevents"
 	"github.com/nats-io/nats.go"
 )
 
+// defaultPresenceStatus is what RefreshPresence records when a telemetry
+// payload carries no explicit status: the entity is clearly alive (it's
+// sending telemetry), just not otherwise self-reporting.
+const defaultPresenceStatus = "online"
+
 type TelemetryWorker struct {
 	*BaseWorker
+	stateCache *statecache.Service
 }
 
-func NewTelemetryWorker(nc *nats.Conn, js nats.JetStreamContext) *TelemetryWorker {
+func NewTelemetryWorker(nc *nats.Conn, js nats.JetStreamContext, config WorkerConfig, stateCache *statecache.Service) *TelemetryWorker {
 	return &TelemetryWorker{
 		BaseWorker: NewBaseWorker(
 			"TelemetryWorker",
@@ -22,20 +30,51 @@ func NewTelemetryWorker(nc *nats.Conn, js nats.JetStreamContext) *TelemetryWorke
 			shared.StreamTelemetry,
 			shared.ConsumerTelemetryProcessor,
 			shared.SubjectTelemetryAll,
+			config,
 		),
+		stateCache: stateCache,
 	}
 }
 
 func (w *TelemetryWorker) Start(ctx context.Context) error {
-	return w.processMessages(ctx, func(msg *nats.Msg) {
-		log.Printf("[%s] Received telemetry message on subject: %s", w.Name(), msg.Subject)
-		
+	return w.processMessages(ctx, func(ctx context.Context, event *cloudevents.Event) error {
+		log.Printf("[%s] Received %s event on subject: %s", w.Name(), event.Type, event.Subject)
+
 		var data map[string]interface{}
-		if err := json.Unmarshal(msg.Data, &data); err != nil {
-			log.Printf("[%s] Raw message data: %s", w.Name(), string(msg.Data))
-		} else {
-			prettyJSON, _ := json.MarshalIndent(data, "", "  ")
-			log.Printf("[%s] Telemetry data:\n%s", w.Name(), string(prettyJSON))
+		if err := event.DataAs(&data); err != nil {
+			log.Printf("[%s] Raw event data: %s", w.Name(), string(event.Data))
+			return nil
 		}
+
+		prettyJSON, _ := json.MarshalIndent(data, "", "  ")
+		log.Printf("[%s] Telemetry data:\n%s", w.Name(), string(prettyJSON))
+
+		w.refreshPresence(data)
+		return nil
 	})
-}
\ No newline at end of file
+}
+
+// refreshPresence renews the sending entity's presence TTL from the
+// telemetry payload's org_id/entity_id, so it keeps appearing on the live
+// roster for as long as telemetry keeps arriving.
+func (w *TelemetryWorker) refreshPresence(data map[string]interface{}) {
+	if w.stateCache == nil {
+		return
+	}
+
+	orgID, _ := data["org_id"].(string)
+	entityID, _ := data["entity_id"].(string)
+	if orgID == "" || entityID == "" {
+		log.Printf("[%s] Telemetry missing org_id/entity_id, skipping presence refresh", w.Name())
+		return
+	}
+
+	status, _ := data["status"].(string)
+	if status == "" {
+		status = defaultPresenceStatus
+	}
+
+	if err := w.stateCache.RefreshPresence(orgID, entityID, status); err != nil {
+		log.Printf("[%s] Failed to refresh presence for %s/%s: %v", w.Name(), orgID, entityID, err)
+	}
+}
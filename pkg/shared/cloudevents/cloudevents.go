@@ -0,0 +1,175 @@
+// Package cloudevents implements the CloudEvents v1.0 envelope
+// (https://github.com/cloudevents/spec/blob/v1.0/spec.md) as the canonical
+// wire format for every JetStream message this service publishes, following
+// the CloudEvents NATS protocol binding: structured mode puts the whole
+// envelope in the message body as JSON, binary mode puts the envelope
+// attributes in "Ce-*" headers and leaves the body as raw event data.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// SpecVersion is the only CloudEvents spec version this package supports.
+const SpecVersion = "1.0"
+
+// Mode selects how Encode lays an Event out on a *nats.Msg.
+type Mode int
+
+const (
+	// ModeStructured serializes the whole envelope as the JSON message body,
+	// the natural fit for messages that are stored and replayed from a
+	// JetStream stream.
+	ModeStructured Mode = iota
+	// ModeBinary carries the envelope attributes as Ce-* headers and leaves
+	// Data as the raw message body, the natural fit for core NATS
+	// request/reply where a non-CloudEvents-aware subscriber may still want
+	// the bare payload.
+	ModeBinary
+)
+
+// Event is a CloudEvents v1.0 envelope. SpecVersion, ID, Source, and Type are
+// required by the spec; the rest are the optional attributes this service
+// uses.
+type Event struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// New builds an Event with SpecVersion and DataContentType defaulted, and
+// data marshaled to JSON.
+func New(id, source, eventType, subject string, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return &Event{
+		ID:              id,
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            raw,
+	}, nil
+}
+
+// DataAs unmarshals e.Data into v.
+func (e *Event) DataAs(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// ceHeader names a binary-mode Ce-* NATS header.
+const (
+	ceHeaderID              = "Ce-Id"
+	ceHeaderSource          = "Ce-Source"
+	ceHeaderSpecVersion     = "Ce-Specversion"
+	ceHeaderType            = "Ce-Type"
+	ceHeaderSubject         = "Ce-Subject"
+	ceHeaderTime            = "Ce-Time"
+	ceHeaderDataContentType = "Content-Type"
+)
+
+// Encode lays e out on msg according to mode. It does not publish msg -
+// callers do that through the normal NATS/JetStream publish path.
+func Encode(msg *nats.Msg, e *Event, mode Mode) error {
+	if e.SpecVersion == "" {
+		e.SpecVersion = SpecVersion
+	}
+
+	switch mode {
+	case ModeBinary:
+		if msg.Header == nil {
+			msg.Header = nats.Header{}
+		}
+		msg.Header.Set(ceHeaderID, e.ID)
+		msg.Header.Set(ceHeaderSource, e.Source)
+		msg.Header.Set(ceHeaderSpecVersion, e.SpecVersion)
+		msg.Header.Set(ceHeaderType, e.Type)
+		if e.Subject != "" {
+			msg.Header.Set(ceHeaderSubject, e.Subject)
+		}
+		if !e.Time.IsZero() {
+			msg.Header.Set(ceHeaderTime, e.Time.Format(time.RFC3339Nano))
+		}
+		if e.DataContentType != "" {
+			msg.Header.Set(ceHeaderDataContentType, e.DataContentType)
+		}
+		msg.Data = e.Data
+		return nil
+
+	case ModeStructured:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal structured event: %w", err)
+		}
+		if msg.Header == nil {
+			msg.Header = nats.Header{}
+		}
+		msg.Header.Set(ceHeaderDataContentType, "application/cloudevents+json")
+		msg.Data = data
+		return nil
+
+	default:
+		return fmt.Errorf("unknown cloudevents mode: %d", mode)
+	}
+}
+
+// Decode reads an Event back off msg, auto-detecting structured mode (a
+// Ce-Specversion header is absent, and the body is a CloudEvents JSON
+// envelope) versus binary mode (a Ce-Specversion header is present).
+func Decode(msg *nats.Msg) (*Event, error) {
+	if msg.Header.Get(ceHeaderSpecVersion) != "" {
+		return decodeBinary(msg)
+	}
+	return decodeStructured(msg)
+}
+
+func decodeBinary(msg *nats.Msg) (*Event, error) {
+	e := &Event{
+		ID:              msg.Header.Get(ceHeaderID),
+		Source:          msg.Header.Get(ceHeaderSource),
+		SpecVersion:     msg.Header.Get(ceHeaderSpecVersion),
+		Type:            msg.Header.Get(ceHeaderType),
+		Subject:         msg.Header.Get(ceHeaderSubject),
+		DataContentType: msg.Header.Get(ceHeaderDataContentType),
+		Data:            msg.Data,
+	}
+
+	if raw := msg.Header.Get(ceHeaderTime); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s header: %w", ceHeaderTime, err)
+		}
+		e.Time = t
+	}
+
+	if e.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("unsupported cloudevents specversion: %q", e.SpecVersion)
+	}
+
+	return e, nil
+}
+
+func decodeStructured(msg *nats.Msg) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(msg.Data, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode structured cloudevents envelope: %w", err)
+	}
+	if e.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("unsupported cloudevents specversion: %q", e.SpecVersion)
+	}
+	return &e, nil
+}
@@ -0,0 +1,118 @@
+// Package httperr provides a typed HTTP error hierarchy so services can
+// return a status code and structured detail without handlers having to
+// compare error strings. It is modeled on etcd's httptypes.HTTPError.
+package httperr
+
+import (
+	"constellation-api/pkg/shared"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// APIError carries everything a handler needs to respond to a failed
+// request: a machine-readable code, a human message, the HTTP status to
+// send, and optionally the underlying cause or validation detail.
+type APIError struct {
+	Code       string
+	Message    string
+	HTTPStatus int
+	Details    string
+	Cause      error
+}
+
+func (e *APIError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+// WithCause returns a copy of e with Cause set, leaving the sentinel
+// constructors below side-effect free.
+func (e *APIError) WithCause(cause error) *APIError {
+	wrapped := *e
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+// WithDetails returns a copy of e with Details set, e.g. the offending field
+// path for a validation failure.
+func (e *APIError) WithDetails(details string) *APIError {
+	wrapped := *e
+	wrapped.Details = details
+	return &wrapped
+}
+
+// WriteTo writes the error using the API's standard shared.Response envelope.
+func (e *APIError) WriteTo(w http.ResponseWriter) {
+	details := e.Details
+	if details == "" && e.Cause != nil {
+		details = e.Cause.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.HTTPStatus)
+	json.NewEncoder(w).Encode(shared.Response{
+		Success: false,
+		Error: &shared.Error{
+			Code:    e.Code,
+			Message: e.Message,
+			Details: details,
+		},
+	})
+}
+
+// ErrNotFound builds a 404 APIError.
+func ErrNotFound(message string) *APIError {
+	return &APIError{Code: "NOT_FOUND", Message: message, HTTPStatus: http.StatusNotFound}
+}
+
+// ErrConflict builds a 409 APIError, e.g. for optimistic concurrency
+// failures.
+func ErrConflict(message string) *APIError {
+	return &APIError{Code: "CONFLICT", Message: message, HTTPStatus: http.StatusConflict}
+}
+
+// ErrVersionConflict builds a 409 APIError specifically for optimistic
+// concurrency control failures on a versioned resource, distinct from
+// ErrConflict so handlers (and clients) can tell a version mismatch apart
+// from other conflicts, e.g. a duplicate unique key.
+func ErrVersionConflict(message string) *APIError {
+	return &APIError{Code: "VERSION_CONFLICT", Message: message, HTTPStatus: http.StatusConflict}
+}
+
+// ErrValidation builds a 400 APIError for malformed or invalid request data.
+func ErrValidation(message string) *APIError {
+	return &APIError{Code: "VALIDATION_FAILED", Message: message, HTTPStatus: http.StatusBadRequest}
+}
+
+// ErrUnauthorized builds a 401 APIError.
+func ErrUnauthorized(message string) *APIError {
+	return &APIError{Code: "UNAUTHORIZED", Message: message, HTTPStatus: http.StatusUnauthorized}
+}
+
+// ErrForbidden builds a 403 APIError, e.g. for a principal that is
+// authenticated but lacks the scope or org membership a request requires.
+func ErrForbidden(message string) *APIError {
+	return &APIError{Code: "FORBIDDEN", Message: message, HTTPStatus: http.StatusForbidden}
+}
+
+// ErrInternal builds a 500 APIError wrapping an unexpected underlying error.
+func ErrInternal(cause error) *APIError {
+	return &APIError{Code: "INTERNAL_ERROR", Message: "internal server error", HTTPStatus: http.StatusInternalServerError, Cause: cause}
+}
+
+// FromError unwraps err looking for an *APIError, falling back to a generic
+// 500 if the error wasn't one a service deliberately raised.
+func FromError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return ErrInternal(err)
+}
@@ -15,10 +15,12 @@ const (
 	SubjectEntityDeleted    = "constellation.entities.%s.deleted"    // org_id
 	SubjectEntityStatus     = "constellation.entities.%s.status"     // org_id
 	SubjectEntityTelemetry  = "constellation.entities.%s.telemetry"  // org_id
-	
+	SubjectEntityBlobAdded  = "constellation.entities.%s.%s.blob_added" // org_id, entity_id
+
 	// Event subjects
 	SubjectEvents    = "constellation.events"
 	SubjectEventsAll = "constellation.events.>"
+	SubjectEventsPurged = "constellation.events.%s.purged.%s" // org_id, table_name
 	
 	// Telemetry subjects
 	SubjectTelemetry        = "constellation.telemetry"
@@ -35,6 +37,10 @@ const (
 	SubjectSystemHealth   = "constellation.system.health"
 	SubjectSystemMetrics  = "constellation.system.metrics"
 	SubjectSystemAlerts   = "constellation.system.alerts"
+
+	// Dead-letter subjects
+	SubjectDLQAll = "constellation.dlq.>"
+	SubjectDLQ    = "constellation.dlq.%s" // original stream name
 )
 
 // Stream names
@@ -43,6 +49,14 @@ const (
 	StreamEvents    = "CONSTELLATION_EVENTS"
 	StreamTelemetry = "CONSTELLATION_TELEMETRY"
 	StreamCommands  = "CONSTELLATION_COMMANDS"
+	StreamDLQ       = "CONSTELLATION_DLQ"
+)
+
+// Key-Value bucket names, created by EmbeddedNATS.CreateStateCacheBuckets
+// and bound by pkg/services/statecache.
+const (
+	KVBucketEntities  = "constellation_entities"
+	KVBucketPresence  = "constellation_presence"
 )
 
 // Consumer names
@@ -74,6 +88,12 @@ func EntityTelemetrySubject(orgID string) string {
 	return fmt.Sprintf(SubjectEntityTelemetry, orgID)
 }
 
+// EntityBlobAddedSubject is where blobstore.Service publishes a CloudEvent
+// after a blob finishes uploading for entityID.
+func EntityBlobAddedSubject(orgID, entityID string) string {
+	return fmt.Sprintf(SubjectEntityBlobAdded, orgID, entityID)
+}
+
 func TelemetryEntitySubject(orgID, entityID string) string {
 	return fmt.Sprintf(SubjectTelemetryEntity, orgID, entityID)
 }
@@ -84,4 +104,14 @@ func CommandEntitySubject(orgID, entityID string) string {
 
 func CommandBroadcastSubject(orgID string) string {
 	return fmt.Sprintf(SubjectCommandBroadcast, orgID)
+}
+
+func EventsPurgedSubject(orgID, tableName string) string {
+	return fmt.Sprintf(SubjectEventsPurged, orgID, tableName)
+}
+
+// DLQSubject returns the CONSTELLATION_DLQ subject a message originally read
+// from streamName is republished to when a worker dead-letters it.
+func DLQSubject(streamName string) string {
+	return fmt.Sprintf(SubjectDLQ, streamName)
 }
\ No newline at end of file
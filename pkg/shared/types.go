@@ -44,6 +44,33 @@ type Event struct {
 	Source    string                 `json:"source"`
 }
 
+// Command is a request/reply instruction published to the commands stream,
+// e.g. by a UI or another service, for CommandWorker to dispatch to the
+// CommandHandler registered for Type.
+type Command struct {
+	ID       string                 `json:"id"`
+	Type     string                 `json:"type"`
+	OrgID    string                 `json:"org_id"`
+	Target   string                 `json:"target,omitempty"`
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	ReplyTo  string                 `json:"reply_to,omitempty"`
+	Deadline *time.Time             `json:"deadline,omitempty"`
+}
+
+// CommandResult is published back to a Command's reply subject (its NATS
+// Msg.Reply if set, else its ReplyTo field) once a handler finishes.
+type CommandResult struct {
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+const (
+	CommandStatusOK    = "ok"
+	CommandStatusError = "error"
+)
+
 // Health check
 type HealthStatus struct {
 	Status    string            `json:"status"`
@@ -88,4 +115,6 @@ const (
 	EventTypeDeleted = "deleted"
 	EventTypeStatus  = "status_changed"
 	EventTypeAlert   = "alert"
+	EventTypePurged  = "purged"
+	EventTypeBlobAdded = "blob_added"
 )
\ No newline at end of file